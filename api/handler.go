@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"sync"
@@ -8,7 +9,17 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/cors"
 	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/pkg/attachment"
 	"github.com/userAdityaa/todo-backend/pkg/auth"
+	"github.com/userAdityaa/todo-backend/pkg/comment"
+	"github.com/userAdityaa/todo-backend/pkg/focus"
+	"github.com/userAdityaa/todo-backend/pkg/habit"
+	"github.com/userAdityaa/todo-backend/pkg/notify"
+	"github.com/userAdityaa/todo-backend/pkg/reminder"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"github.com/userAdityaa/todo-backend/pkg/storage"
+	"github.com/userAdityaa/todo-backend/pkg/timetrack"
 	"github.com/userAdityaa/todo-backend/routes"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -39,7 +50,7 @@ func init() {
 		router = chi.NewMux()
 		corsHandler := cors.New(cors.Options{
 			AllowedOrigins:   []string{"https://minimal-planner.vercel.app"},
-			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 			ExposedHeaders:   []string{"Link"},
 			AllowCredentials: true,
@@ -50,17 +61,105 @@ func init() {
 		router.HandleFunc("/auth/google/login", auth.GoogleLoginHandler)
 		router.HandleFunc("/auth/google/callback", auth.GoogleCallBackHandler(database))
 		router.Get("/auth/user", auth.GetUserDetailsHandler(database))
+		router.Put("/auth/user/timezone", auth.UpdateTimeZoneHandler(database))
 
 		todoCollection := config.TodoCollection(database)
 		userCollection := config.UserCollection(database)
 		stickyCollection := config.StickyCollection(database)
 		listCollection := config.ListCollection(database)
 		eventCollection := config.EventCollection(database)
+		reminderCollection := config.ReminderCollection(database)
+		activityCollection := config.ActivityCollection(database)
+		timeEntryCollection := config.TimeEntryCollection(database)
+		focusCollection := config.FocusSessionCollection(database)
+		habitCollection := config.HabitCollection(database)
+		habitCheckInCollection := config.HabitCheckInCollection(database)
+		templateCollection := config.TemplateCollection(database)
+		attachmentCollection := config.AttachmentCollection(database)
+		commentCollection := config.CommentCollection(database)
+		listMemberCollection := config.ListMemberCollection(database)
+		listInviteCollection := config.ListInviteCollection(database)
+
+		// Data migrations scan whole collections and are run once per deploy
+		// with cmd/migrate, not on every cold start.
 
 		routes.SetUpTodoRoutes(router, todoCollection, userCollection)
 		routes.SetUpStickyRoutes(router, stickyCollection, userCollection)
-		routes.SetUpListRoutes(router, listCollection, userCollection)
+		routes.SetUpListRoutes(router, listCollection, userCollection, todoCollection)
 		routes.SetUpEventRoutes(router, eventCollection, userCollection)
+		routes.SetUpTagRoutes(router, todoCollection, userCollection)
+		routes.SetUpSearchRoutes(router, userCollection)
+		routes.SetUpActivityRoutes(router, activityCollection, userCollection)
+		routes.SetUpTimeTrackRoutes(router, timeEntryCollection, userCollection)
+		routes.SetUpFocusRoutes(router, focusCollection, userCollection)
+		routes.SetUpHabitRoutes(router, habitCollection, habitCheckInCollection, userCollection)
+		routes.SetUpStatsRoutes(router, userCollection)
+		routes.SetUpTemplateRoutes(router, templateCollection, todoCollection, userCollection)
+		routes.SetUpCommentRoutes(router, commentCollection, userCollection)
+
+		if err := activity.EnsureIndexes(context.Background(), database); err != nil {
+			setupError = err
+			return
+		}
+
+		if err := timetrack.EnsureIndexes(context.Background(), database); err != nil {
+			setupError = err
+			return
+		}
+
+		if err := focus.EnsureIndexes(context.Background(), database); err != nil {
+			setupError = err
+			return
+		}
+
+		if err := habit.EnsureIndexes(context.Background(), database); err != nil {
+			setupError = err
+			return
+		}
+
+		if err := comment.EnsureIndexes(context.Background(), database); err != nil {
+			setupError = err
+			return
+		}
+
+		if err := share.EnsureIndexes(context.Background(), database); err != nil {
+			setupError = err
+			return
+		}
+
+		if err := attachment.EnsureIndexes(context.Background(), database); err != nil {
+			setupError = err
+			return
+		}
+
+		store, err := storage.FromConfig()
+		if err != nil {
+			setupError = err
+			return
+		}
+		routes.SetUpAttachmentRoutes(router, attachmentCollection, userCollection, store, config.CronSecret)
+
+		if config.AttachmentSweepInterval > 0 {
+			go attachment.RunSweeper(context.Background(), database, store, config.AttachmentSweepInterval)
+		}
+
+		notifiers, err := notify.FromConfig()
+		if err != nil {
+			setupError = err
+			return
+		}
+
+		scheduler := reminder.NewScheduler(reminderCollection, userCollection, notifiers)
+		if err := scheduler.EnsureIndexes(context.Background()); err != nil {
+			setupError = err
+			return
+		}
+		routes.SetUpReminderRoutes(router, scheduler, userCollection, config.CronSecret)
+		routes.SetUpShareRoutes(router, listMemberCollection, listInviteCollection, userCollection, scheduler.Notifier(notify.ChannelEmail))
+
+		if config.ReminderTickInterval > 0 {
+			go scheduler.Run(context.Background(), config.ReminderTickInterval)
+		}
 	})
 }
 
@@ -0,0 +1,44 @@
+// Command migrate rewrites documents stored in older shapes. Each migration
+// scans whole collections, so it runs once per deploy from here rather than
+// on every cold start of the API:
+//
+//	go run ./cmd/migrate
+//
+// The migrations only touch documents still in the old shape, so running
+// them again is harmless.
+package main
+
+import (
+	"log"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/pkg/todo"
+)
+
+func main() {
+	db, err := config.SetUpDataBase()
+	if err != nil {
+		log.Fatal("Failed to connect to the database: ", err)
+	}
+
+	todoCollection := config.TodoCollection(db)
+	userCollection := config.UserCollection(db)
+	listCollection := config.ListCollection(db)
+
+	migrations := []struct {
+		name string
+		run  func() error
+	}{
+		{"subtasks", func() error { return todo.MigrateSubtasks(todoCollection, userCollection) }},
+		{"due dates", func() error { return todo.MigrateDueDates(todoCollection, userCollection) }},
+		{"list references", func() error { return todo.MigrateListRefs(todoCollection, listCollection, userCollection) }},
+	}
+
+	for _, migration := range migrations {
+		log.Println("Migrating", migration.name)
+		if err := migration.run(); err != nil {
+			log.Fatalf("Migrating %s failed: %v", migration.name, err)
+		}
+	}
+	log.Println("Migrations finished")
+}
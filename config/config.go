@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -14,6 +17,34 @@ var (
 	GoogleClientID     string
 	GoogleClientSecret string
 	GoogleRedirectURL  string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+	WebhookSecret   string
+
+	CronSecret           string
+	ReminderTickInterval time.Duration
+
+	StorageBackend    string
+	StorageLocalDir   string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3ForcePathStyle  bool
+
+	AttachmentMaxBytes      int64
+	AttachmentURLSecret     string
+	AttachmentSweepInterval time.Duration
+
+	InviteURLBase string
 )
 
 func loadEnv() error {
@@ -45,6 +76,65 @@ func LoadConfig() error {
 	if GoogleClientID == "" || GoogleClientSecret == "" || GoogleRedirectURL == "" {
 		return fmt.Errorf("missing required environment variables")
 	}
+
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = os.Getenv("SMTP_PORT")
+	if SMTPPort == "" {
+		SMTPPort = "587"
+	}
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	SMTPFrom = os.Getenv("SMTP_FROM")
+
+	VAPIDPrivateKey = os.Getenv("VAPID_PRIVATE_KEY")
+	VAPIDSubject = os.Getenv("VAPID_SUBJECT")
+	WebhookSecret = os.Getenv("WEBHOOK_SECRET")
+
+	CronSecret = os.Getenv("CRON_SECRET")
+	if value := os.Getenv("REMINDER_TICK_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid REMINDER_TICK_INTERVAL: %v", err)
+		}
+		ReminderTickInterval = interval
+	}
+
+	StorageBackend = os.Getenv("STORAGE_BACKEND")
+	StorageLocalDir = os.Getenv("STORAGE_LOCAL_DIR")
+	if StorageLocalDir == "" {
+		StorageLocalDir = filepath.Join(os.TempDir(), "attachments")
+	}
+	S3Endpoint = os.Getenv("S3_ENDPOINT")
+	S3Region = os.Getenv("S3_REGION")
+	if S3Region == "" {
+		S3Region = "us-east-1"
+	}
+	S3Bucket = os.Getenv("S3_BUCKET")
+	S3AccessKeyID = os.Getenv("S3_ACCESS_KEY_ID")
+	S3SecretAccessKey = os.Getenv("S3_SECRET_ACCESS_KEY")
+	S3ForcePathStyle = os.Getenv("S3_FORCE_PATH_STYLE") == "true"
+
+	AttachmentMaxBytes = 10 << 20
+	if value := os.Getenv("ATTACHMENT_MAX_BYTES"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("invalid ATTACHMENT_MAX_BYTES: %q", value)
+		}
+		AttachmentMaxBytes = limit
+	}
+	AttachmentURLSecret = os.Getenv("ATTACHMENT_URL_SECRET")
+	if value := os.Getenv("ATTACHMENT_SWEEP_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid ATTACHMENT_SWEEP_INTERVAL: %v", err)
+		}
+		AttachmentSweepInterval = interval
+	}
+
+	InviteURLBase = os.Getenv("INVITE_URL_BASE")
+	if InviteURLBase == "" {
+		InviteURLBase = "https://minimal-planner.vercel.app/invites/"
+	}
 	return nil
 }
 
@@ -96,3 +186,47 @@ func ListCollection(database *mongo.Database) *mongo.Collection {
 func EventCollection(database *mongo.Database) *mongo.Collection {
 	return database.Collection("event")
 }
+
+func ReminderCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("reminder")
+}
+
+func ActivityCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("activity")
+}
+
+func TimeEntryCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("time_entry")
+}
+
+func FocusSessionCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("focus_session")
+}
+
+func HabitCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("habit")
+}
+
+func HabitCheckInCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("habit_checkin")
+}
+
+func TemplateCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("template")
+}
+
+func AttachmentCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("attachment")
+}
+
+func CommentCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("comment")
+}
+
+func ListMemberCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("list_member")
+}
+
+func ListInviteCollection(database *mongo.Database) *mongo.Collection {
+	return database.Collection("list_invite")
+}
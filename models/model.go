@@ -1,49 +1,334 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type List struct {
-	ID    primitive.ObjectID `json:"id" bson:"_id"`
-	Name  string             `json:"name" bson:"name"`
-	Color string             `json:"color" bson:"color"`
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Name      string             `json:"name" bson:"name"`
+	Color     string             `json:"color" bson:"color"`
+	Icon      string             `json:"icon" bson:"icon"`
+	Position  int                `json:"position" bson:"position"`
+	Columns   []Column           `json:"columns" bson:"columns"`
+	Role      string             `json:"role,omitempty" bson:"-"`
+	OwnerID   string             `json:"owner_id,omitempty" bson:"-"`
+	OwnerName string             `json:"owner_name,omitempty" bson:"-"`
+}
+
+type Column struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id"`
+	Name     string             `json:"name" bson:"name"`
+	WIPLimit int                `json:"wip_limit" bson:"wip_limit"`
 }
 
 type Todo struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id"`
-	Name        string             `json:"name" bson:"name"`
-	Description string             `json:"description" bson:"description"`
-	List        string             `json:"list" bson:"list"`
-	DueDate     string             `json:"due_date" bson:"due_date"`
-	Subtask     []string           `json:"sub_task" bson:"sub_task"`
+	ID           primitive.ObjectID   `json:"id" bson:"_id"`
+	Name         string               `json:"name" bson:"name"`
+	Description  string               `json:"description" bson:"description"`
+	ListID       primitive.ObjectID   `json:"list_id" bson:"list_id"`
+	DueDate      *time.Time           `json:"due_date" bson:"due_date"`
+	AllDay       bool                 `json:"all_day" bson:"all_day"`
+	TimeZone     string               `json:"timezone" bson:"timezone"`
+	Subtask      []Subtask            `json:"sub_task" bson:"sub_task"`
+	Rank         string               `json:"rank" bson:"rank"`
+	Priority     int                  `json:"priority" bson:"priority"`
+	Tags         []string             `json:"tags" bson:"tags"`
+	BlockedBy    []primitive.ObjectID `json:"blocked_by" bson:"blocked_by"`
+	Done         bool                 `json:"done" bson:"done"`
+	CompletedAt  *time.Time           `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	Recurrence   *Recurrence          `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	Reminders    []Reminder           `json:"reminders" bson:"reminders"`
+	Estimate     int                  `json:"estimate_minutes" bson:"estimate_minutes"`
+	Attachments  []Attachment         `json:"attachments" bson:"attachments"`
+	CommentCount int                  `json:"comment_count" bson:"comment_count"`
+	ColumnID     primitive.ObjectID   `json:"column_id" bson:"column_id"`
+	Progress     SubtaskProgress      `json:"progress" bson:"-"`
+	Overdue      bool                 `json:"overdue" bson:"-"`
+	DueToday     bool                 `json:"due_today" bson:"-"`
+	Blocked      bool                 `json:"blocked" bson:"-"`
+}
+
+type Subtask struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id"`
+	Title    string             `json:"title" bson:"title"`
+	Done     bool               `json:"done" bson:"done"`
+	Position int                `json:"position" bson:"position"`
+}
+
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+type Tag struct {
+	Name  string `json:"name" bson:"name"`
+	Color string `json:"color" bson:"color"`
+}
+
+type Recurrence struct {
+	Rule     string             `json:"rule" bson:"rule"`
+	SeriesID primitive.ObjectID `json:"series_id" bson:"series_id"`
+	Start    time.Time          `json:"start" bson:"start"`
+	Index    int                `json:"index" bson:"index"`
+	Template *Todo              `json:"-" bson:"template,omitempty"`
+	// NextID is the occurrence spawned when this one was completed.
+	NextID primitive.ObjectID `json:"next_id,omitempty" bson:"next_id,omitempty"`
+}
+
+type Reminder struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	At            *time.Time         `json:"at,omitempty" bson:"at,omitempty"`
+	OffsetMinutes int                `json:"offset_minutes" bson:"offset_minutes"`
+	Channels      []string           `json:"channels" bson:"channels"`
+}
+
+type PushKeys struct {
+	P256dh string `json:"p256dh" bson:"p256dh"`
+	Auth   string `json:"auth" bson:"auth"`
+}
+
+type PushSubscription struct {
+	Endpoint string   `json:"endpoint" bson:"endpoint"`
+	Keys     PushKeys `json:"keys" bson:"keys"`
+}
+
+type NotificationSettings struct {
+	DefaultChannels   []string           `json:"default_channels" bson:"default_channels"`
+	WebhookURL        string             `json:"webhook_url" bson:"webhook_url"`
+	PushSubscriptions []PushSubscription `json:"push_subscriptions" bson:"push_subscriptions"`
+}
+
+type SubtaskProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+func (s *Subtask) UnmarshalJSON(data []byte) error {
+	var title string
+	if err := json.Unmarshal(data, &title); err == nil {
+		*s = Subtask{Title: title}
+		return nil
+	}
+
+	type plain Subtask
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*s = Subtask(p)
+	return nil
 }
 
 type User struct {
-	ID      string   `json:"id" bson:"_id"`
-	Name    string   `json:"name" bson:"username"`
-	Email   string   `json:"email" bson:"email"`
-	Picture string   `json:"picture" bson:"picture"`
-	Todo    []Todo   `json:"todos" bson:"todos"`
-	Stick   []Sticky `json:"sticky" bson:"sticky"`
-	List    []List   `json:"list" bson:"list"`
-	Event   []Event  `json:"event" bson:"event"`
+	ID            string               `json:"id" bson:"_id"`
+	Name          string               `json:"name" bson:"username"`
+	Email         string               `json:"email" bson:"email"`
+	Picture       string               `json:"picture" bson:"picture"`
+	TimeZone      string               `json:"timezone" bson:"timezone"`
+	Todo          []Todo               `json:"todos" bson:"todos"`
+	Stick         []Sticky             `json:"sticky" bson:"sticky"`
+	List          []List               `json:"list" bson:"list"`
+	Event         []Event              `json:"event" bson:"event"`
+	Tags          []Tag                `json:"tags" bson:"tags"`
+	Notifications NotificationSettings `json:"notifications" bson:"notifications"`
+	Focus         FocusSettings        `json:"focus" bson:"focus"`
+	Habits        []Habit              `json:"habits" bson:"habits"`
 }
 
 type Sticky struct {
-	ID      primitive.ObjectID `json:"id" bson:"_id"`
-	Topic   string             `json:"topic" bson:"topic"`
-	Content string             `json:"content" bson:"content"`
-	Color   string             `json:"color" bson:"color"`
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Topic       string             `json:"topic" bson:"topic"`
+	Content     string             `json:"content" bson:"content"`
+	Color       string             `json:"color" bson:"color"`
+	Attachments []Attachment       `json:"attachments" bson:"attachments"`
 }
 
 type Event struct {
-	ID    primitive.ObjectID `json:"id" bson:"_id"`
-	Title string             `json:"title" bson:"title"`
-	Date  time.Time          `json:"date" bson:"date"`
-	Color string             `json:"color" bson:"color"`
-	Start time.Time          `json:"start" bson:"start"`
-	End   time.Time          `json:"end" bson:"end"`
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Title     string             `json:"title" bson:"title"`
+	Date      time.Time          `json:"date" bson:"date"`
+	Color     string             `json:"color" bson:"color"`
+	Start     time.Time          `json:"start" bson:"start"`
+	End       time.Time          `json:"end" bson:"end"`
+	Reminders []Reminder         `json:"reminders" bson:"reminders"`
+}
+
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+type Activity struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id"`
+	UserID    string               `json:"-" bson:"user_id"`
+	AccountID string               `json:"-" bson:"account_id,omitempty"`
+	Group     primitive.ObjectID   `json:"group" bson:"group"`
+	ItemType  string               `json:"item_type" bson:"item_type"`
+	ItemID    primitive.ObjectID   `json:"item_id" bson:"item_id"`
+	Action    string               `json:"action" bson:"action"`
+	Changes   []FieldChange        `json:"changes" bson:"changes"`
+	Before    bson.M               `json:"before,omitempty" bson:"before,omitempty"`
+	After     bson.M               `json:"after,omitempty" bson:"after,omitempty"`
+	AfterHash string               `json:"-" bson:"after_hash,omitempty"`
+	Reverts   []primitive.ObjectID `json:"reverts,omitempty" bson:"reverts,omitempty"`
+	CreatedAt time.Time            `json:"created_at" bson:"created_at"`
+	Undone    bool                 `json:"undone" bson:"-"`
+}
+
+type TimeEntry struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id"`
+	UserID          string             `json:"-" bson:"user_id"`
+	TodoID          primitive.ObjectID `json:"todo_id" bson:"todo_id"`
+	Start           time.Time          `json:"start" bson:"start"`
+	End             *time.Time         `json:"end" bson:"end"`
+	DurationSeconds int64              `json:"duration_seconds" bson:"duration_seconds"`
+	Note            string             `json:"note" bson:"note"`
+	Manual          bool               `json:"manual" bson:"manual"`
+	Running         bool               `json:"running" bson:"running,omitempty"`
+}
+
+type FocusSettings struct {
+	WorkMinutes       int `json:"work_minutes" bson:"work_minutes"`
+	ShortBreakMinutes int `json:"short_break_minutes" bson:"short_break_minutes"`
+	LongBreakMinutes  int `json:"long_break_minutes" bson:"long_break_minutes"`
+	LongBreakEvery    int `json:"long_break_every" bson:"long_break_every"`
+}
+
+type FocusSession struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id"`
+	UserID         string              `json:"-" bson:"user_id"`
+	TodoID         *primitive.ObjectID `json:"todo_id,omitempty" bson:"todo_id,omitempty"`
+	Kind           string              `json:"kind" bson:"kind"`
+	State          string              `json:"state" bson:"state"`
+	PlannedSeconds int64               `json:"planned_seconds" bson:"planned_seconds"`
+	StartedAt      time.Time           `json:"started_at" bson:"started_at"`
+	PausedAt       *time.Time          `json:"paused_at,omitempty" bson:"paused_at,omitempty"`
+	PausedSeconds  int64               `json:"paused_seconds" bson:"paused_seconds"`
+	EndedAt        *time.Time          `json:"ended_at,omitempty" bson:"ended_at,omitempty"`
+	FocusedSeconds int64               `json:"focused_seconds" bson:"focused_seconds"`
+	Completed      bool                `json:"completed" bson:"completed"`
+	Active         bool                `json:"-" bson:"active,omitempty"`
+	Remaining      int64               `json:"remaining_seconds" bson:"-"`
+	EndsAt         *time.Time          `json:"ends_at,omitempty" bson:"-"`
+}
+
+const (
+	HabitDaily  = "daily"
+	HabitWeekly = "weekly"
+)
+
+type Habit struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	Color       string             `json:"color" bson:"color"`
+	Frequency   string             `json:"frequency" bson:"frequency"`
+	Target      int                `json:"target" bson:"target"`
+	Days        []int              `json:"days" bson:"days"`
+	Archived    bool               `json:"archived" bson:"archived"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	Stats       *HabitStats        `json:"stats,omitempty" bson:"-"`
+}
+
+type HabitStats struct {
+	CurrentStreak  int     `json:"current_streak"`
+	LongestStreak  int     `json:"longest_streak"`
+	CompletionRate float64 `json:"completion_rate"`
+	PeriodCount    int     `json:"period_count"`
+	PeriodDone     bool    `json:"period_done"`
+}
+
+type HabitCheckIn struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	UserID    string             `json:"-" bson:"user_id"`
+	HabitID   primitive.ObjectID `json:"habit_id" bson:"habit_id"`
+	Date      string             `json:"date" bson:"date"`
+	Count     int                `json:"count" bson:"count"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type TemplateTodo struct {
+	Name          string   `json:"name" bson:"name"`
+	Description   string   `json:"description" bson:"description"`
+	Priority      int      `json:"priority" bson:"priority"`
+	Tags          []string `json:"tags" bson:"tags"`
+	Subtasks      []string `json:"subtasks" bson:"subtasks"`
+	Estimate      int      `json:"estimate_minutes" bson:"estimate_minutes"`
+	DueOffsetDays *int     `json:"due_offset_days,omitempty" bson:"due_offset_days,omitempty"`
+	DueTime       string   `json:"due_time,omitempty" bson:"due_time,omitempty"`
+}
+
+type Template struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	UserID      string             `json:"-" bson:"user_id"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	ListName    string             `json:"list_name" bson:"list_name"`
+	ListColor   string             `json:"list_color" bson:"list_color"`
+	Todos       []TemplateTodo     `json:"todos" bson:"todos"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type Attachment struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Name        string             `json:"name" bson:"name"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Size        int64              `json:"size" bson:"size"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type Mention struct {
+	UserID string `json:"user_id" bson:"user_id"`
+	Name   string `json:"name" bson:"name"`
+	Email  string `json:"email" bson:"email"`
+}
+
+type Comment struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	TodoID        primitive.ObjectID `json:"todo_id" bson:"todo_id"`
+	OwnerID       string             `json:"-" bson:"owner_id"`
+	AuthorID      string             `json:"author_id" bson:"author_id"`
+	AuthorName    string             `json:"author_name" bson:"author_name"`
+	AuthorPicture string             `json:"author_picture" bson:"author_picture"`
+	Body          string             `json:"body" bson:"body"`
+	Mentions      []Mention          `json:"mentions" bson:"mentions"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	EditedAt      *time.Time         `json:"edited_at,omitempty" bson:"edited_at,omitempty"`
+}
+
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+type ListMember struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id"`
+	ListID   primitive.ObjectID `json:"list_id" bson:"list_id"`
+	OwnerID  string             `json:"owner_id" bson:"owner_id"`
+	UserID   string             `json:"user_id" bson:"user_id"`
+	Name     string             `json:"name" bson:"name"`
+	Email    string             `json:"email" bson:"email"`
+	Picture  string             `json:"picture" bson:"picture"`
+	Role     string             `json:"role" bson:"role"`
+	JoinedAt time.Time          `json:"joined_at" bson:"joined_at"`
+}
+
+type ListInvite struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	ListID    primitive.ObjectID `json:"list_id" bson:"list_id"`
+	OwnerID   string             `json:"-" bson:"owner_id"`
+	Email     string             `json:"email,omitempty" bson:"email,omitempty"`
+	Role      string             `json:"role" bson:"role"`
+	TokenHash string             `json:"-" bson:"token_hash"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
 }
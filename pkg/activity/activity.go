@@ -0,0 +1,297 @@
+package activity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ItemTodo   = "todo"
+	ItemSticky = "sticky"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionUndo   = "undo"
+)
+
+var userArrays = map[string]string{
+	ItemTodo:   "todos",
+	ItemSticky: "sticky",
+}
+
+// derivedFields are kept up to date by other features behind the activity
+// log's back, so they are left out of snapshots. Otherwise a new comment or
+// attachment would make every earlier edit of the item fail its undo check.
+var derivedFields = map[string][]string{
+	ItemTodo:   {"attachments", "comment_count"},
+	ItemSticky: {"attachments"},
+}
+
+func itemCollection(db *mongo.Database, itemType string) *mongo.Collection {
+	if itemType == ItemSticky {
+		return config.StickyCollection(db)
+	}
+	return config.TodoCollection(db)
+}
+
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := config.ActivityCollection(db).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "item_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "reverts", Value: 1}}},
+	})
+	return err
+}
+
+func hash(snapshot bson.Raw) string {
+	if snapshot == nil {
+		return ""
+	}
+	sum := sha256.Sum256(snapshot)
+	return hex.EncodeToString(sum[:])
+}
+
+// element reads an item as it is stored in the user document.
+func element(ctx context.Context, db *mongo.Database, userID string, itemType string, itemID primitive.ObjectID) (bson.Raw, error) {
+	field, ok := userArrays[itemType]
+	if !ok {
+		return nil, fmt.Errorf("unknown item type %q", itemType)
+	}
+
+	var doc bson.Raw
+	err := config.UserCollection(db).FindOne(
+		ctx,
+		bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{field: bson.M{"$elemMatch": bson.M{"_id": itemID}}}),
+	).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := doc.LookupErr(field)
+	if err != nil {
+		return nil, nil
+	}
+	array, ok := value.ArrayOK()
+	if !ok {
+		return nil, nil
+	}
+	values, err := array.Values()
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	item, ok := values[0].DocumentOK()
+	if !ok {
+		return nil, nil
+	}
+	return item, nil
+}
+
+// snapshot reads an item from the user document, which is what every read
+// endpoint serves, and re-encodes it through its model so two snapshots of
+// the same state are byte-for-byte equal.
+func snapshot(ctx context.Context, db *mongo.Database, userID string, itemType string, itemID primitive.ObjectID) (bson.Raw, error) {
+	raw, err := element(ctx, db, userID, itemType, itemID)
+	if raw == nil || err != nil {
+		return nil, err
+	}
+
+	var item interface{}
+	switch itemType {
+	case ItemSticky:
+		var sticky models.Sticky
+		err = bson.Unmarshal(raw, &sticky)
+		item = sticky
+	default:
+		var todo models.Todo
+		err = bson.Unmarshal(raw, &todo)
+		item = todo
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := bson.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(encoded, &doc); err != nil {
+		return nil, err
+	}
+
+	derived := make(map[string]bool)
+	for _, name := range derivedFields[itemType] {
+		derived[name] = true
+	}
+	kept := make(bson.D, 0, len(doc))
+	for _, field := range doc {
+		if !derived[field.Key] {
+			kept = append(kept, field)
+		}
+	}
+	return bson.Marshal(kept)
+}
+
+func decode(snapshot bson.Raw) bson.M {
+	if snapshot == nil {
+		return nil
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(snapshot, &doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+func diff(before bson.Raw, after bson.Raw) []models.FieldChange {
+	changes := make([]models.FieldChange, 0)
+	seen := make(map[string]bool)
+
+	var keys []string
+	for _, doc := range []bson.Raw{after, before} {
+		elements, _ := doc.Elements()
+		for _, element := range elements {
+			if key := element.Key(); !seen[key] && key != "_id" {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	beforeDoc, afterDoc := decode(before), decode(after)
+	for _, key := range keys {
+		var old, updated bson.RawValue
+		if before != nil {
+			old = before.Lookup(key)
+		}
+		if after != nil {
+			updated = after.Lookup(key)
+		}
+		if old.Equal(updated) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: key, Before: beforeDoc[key], After: afterDoc[key]})
+	}
+	return changes
+}
+
+type tracked struct {
+	accountID string
+	itemType  string
+	itemID    primitive.ObjectID
+	before    bson.Raw
+}
+
+// Recorder collects the items a request is about to change and records one
+// activity entry per changed item once the request is done. Entries from the
+// same recorder share a group so they are undone together.
+//
+// Items live in the document of the account that owns them, which for a
+// shared list is not the user making the change. Entries go to the user's
+// own log either way.
+type Recorder struct {
+	db        *mongo.Database
+	userID    string
+	accountID string
+	group     primitive.ObjectID
+	tracked   []tracked
+}
+
+// NewRecorder records changes made by userID to items of accountID. Both
+// are the same unless the items sit in a list shared with the user.
+func NewRecorder(db *mongo.Database, accountID string, userID string) *Recorder {
+	return &Recorder{db: db, userID: userID, accountID: accountID, group: primitive.NewObjectID()}
+}
+
+func (r *Recorder) snapshot(accountID string, itemType string, itemID primitive.ObjectID) bson.Raw {
+	snap, err := snapshot(context.TODO(), r.db, accountID, itemType, itemID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		log.Println("Error taking activity snapshot:", err)
+	}
+	return snap
+}
+
+func (r *Recorder) Track(itemType string, itemIDs ...primitive.ObjectID) {
+	r.TrackIn(r.accountID, itemType, itemIDs...)
+}
+
+// TrackIn is Track for items of another account than the recorder's, for
+// requests that reach into several.
+func (r *Recorder) TrackIn(accountID string, itemType string, itemIDs ...primitive.ObjectID) {
+	for _, itemID := range itemIDs {
+		alreadyTracked := false
+		for _, t := range r.tracked {
+			alreadyTracked = alreadyTracked || (t.itemType == itemType && t.itemID == itemID)
+		}
+		if !alreadyTracked {
+			r.tracked = append(r.tracked, tracked{accountID: accountID, itemType: itemType, itemID: itemID, before: r.snapshot(accountID, itemType, itemID)})
+		}
+	}
+}
+
+func (r *Recorder) Commit() {
+	for _, t := range r.tracked {
+		after := r.snapshot(t.accountID, t.itemType, t.itemID)
+		if err := r.insert(t.accountID, t.itemType, t.itemID, t.before, after, "", nil); err != nil {
+			log.Println("Error recording activity:", err)
+		}
+	}
+	r.tracked = nil
+}
+
+func (r *Recorder) insert(accountID string, itemType string, itemID primitive.ObjectID, before bson.Raw, after bson.Raw, action string, reverts []primitive.ObjectID) error {
+	if action == "" {
+		switch {
+		case before == nil && after == nil:
+			return nil
+		case before == nil:
+			action = ActionCreate
+		case after == nil:
+			action = ActionDelete
+		default:
+			action = ActionUpdate
+		}
+	}
+
+	changes := diff(before, after)
+	if action == ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	entry := models.Activity{
+		ID:        primitive.NewObjectID(),
+		UserID:    r.userID,
+		Group:     r.group,
+		ItemType:  itemType,
+		ItemID:    itemID,
+		Action:    action,
+		Changes:   changes,
+		Before:    decode(before),
+		After:     decode(after),
+		AfterHash: hash(after),
+		Reverts:   reverts,
+		CreatedAt: time.Now(),
+	}
+	if accountID != r.userID {
+		entry.AccountID = accountID
+	}
+
+	_, err := config.ActivityCollection(r.db).InsertOne(context.TODO(), entry)
+	return err
+}
@@ -0,0 +1,212 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func GetActivity(activityCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		values := r.URL.Query()
+		filter := bson.M{"user_id": user.ID}
+
+		if value := values.Get("item_id"); value != "" {
+			itemID, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				http.Error(w, "Invalid item_id", http.StatusBadRequest)
+				return
+			}
+			filter["item_id"] = itemID
+		}
+
+		if value := values.Get("item_type"); value != "" {
+			if _, ok := userArrays[value]; !ok {
+				http.Error(w, "item_type must be todo or sticky", http.StatusBadRequest)
+				return
+			}
+			filter["item_type"] = value
+		}
+
+		if value := values.Get("cursor"); value != "" {
+			cursorID, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				http.Error(w, "Invalid cursor", http.StatusBadRequest)
+				return
+			}
+			filter["_id"] = bson.M{"$lt": cursorID}
+		}
+
+		limit := defaultPageSize
+		if value := values.Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxPageSize {
+				http.Error(w, "Limit must be between 1 and 200", http.StatusBadRequest)
+				return
+			}
+		}
+
+		cursor, err := activityCollection.Find(
+			context.TODO(),
+			filter,
+			options.Find().SetSort(bson.M{"_id": -1}).SetLimit(int64(limit+1)),
+		)
+		if err != nil {
+			http.Error(w, "Failed to fetch activity", http.StatusInternalServerError)
+			return
+		}
+
+		entries := make([]models.Activity, 0)
+		if err := cursor.All(context.TODO(), &entries); err != nil {
+			http.Error(w, "Failed to fetch activity", http.StatusInternalServerError)
+			return
+		}
+
+		var nextCursor *string
+		if len(entries) > limit {
+			entries = entries[:limit]
+			next := entries[limit-1].ID.Hex()
+			nextCursor = &next
+		}
+
+		reverted, err := revertedIDs(context.TODO(), activityCollection, user.ID, entryIDs(entries))
+		if err != nil {
+			http.Error(w, "Failed to fetch activity", http.StatusInternalServerError)
+			return
+		}
+		for i := range entries {
+			entries[i].Undone = reverted[entries[i].ID]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(map[string]interface{}{
+			"items":       entries,
+			"next_cursor": nextCursor,
+		})
+		if err != nil {
+			log.Println("Error encoding activity:", err)
+			http.Error(w, "Failed to fetch activity", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func UndoActivity(activityCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var undoRequest struct {
+			ActivityID *primitive.ObjectID `json:"activity_id,omitempty"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&undoRequest); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		undone, err := Undo(context.TODO(), activityCollection.Database(), user.ID, undoRequest.ActivityID)
+
+		var conflict *ConflictError
+		switch {
+		case errors.As(err, &conflict):
+			http.Error(w, "Cannot undo: "+conflict.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, ErrNothingToUndo), errors.Is(err, mongo.ErrNoDocuments):
+			http.Error(w, "Nothing to undo", http.StatusNotFound)
+			return
+		case errors.Is(err, ErrAlreadyUndone), errors.Is(err, ErrNotUndoable):
+			http.Error(w, "Cannot undo: "+err.Error(), http.StatusConflict)
+			return
+		case errors.Is(err, ErrNoAccess):
+			http.Error(w, "Cannot undo: "+err.Error(), http.StatusForbidden)
+			return
+		case err != nil:
+			log.Println("Error undoing activity:", err)
+			http.Error(w, "Failed to undo activity", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Activity undone successfully",
+			"undone":  undone,
+		})
+	}
+}
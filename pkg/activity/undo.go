@@ -0,0 +1,278 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxUndoLookback = 50
+
+var (
+	ErrNothingToUndo = errors.New("nothing to undo")
+	ErrAlreadyUndone = errors.New("activity has already been undone")
+	ErrNotUndoable   = errors.New("undo entries cannot be undone")
+	ErrNoAccess      = errors.New("you no longer have edit access to this item")
+)
+
+type ConflictError struct {
+	ItemType string
+	ItemID   primitive.ObjectID
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %s has changed since this activity", e.ItemType, e.ItemID.Hex())
+}
+
+func groupEntries(ctx context.Context, activities *mongo.Collection, userID string, group primitive.ObjectID) ([]models.Activity, error) {
+	cursor, err := activities.Find(
+		ctx,
+		bson.M{"user_id": userID, "group": group},
+		options.Find().SetSort(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []models.Activity
+	err = cursor.All(ctx, &entries)
+	return entries, err
+}
+
+func entryIDs(entries []models.Activity) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+func revertedIDs(ctx context.Context, activities *mongo.Collection, userID string, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	cursor, err := activities.Find(ctx, bson.M{"user_id": userID, "action": ActionUndo, "reverts": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	var undos []models.Activity
+	if err := cursor.All(ctx, &undos); err != nil {
+		return nil, err
+	}
+
+	reverted := make(map[primitive.ObjectID]bool)
+	for _, undo := range undos {
+		for _, id := range undo.Reverts {
+			reverted[id] = true
+		}
+	}
+	return reverted, nil
+}
+
+func latestGroup(ctx context.Context, activities *mongo.Collection, userID string) ([]models.Activity, error) {
+	cursor, err := activities.Find(
+		ctx,
+		bson.M{"user_id": userID, "action": bson.M{"$ne": ActionUndo}},
+		options.Find().SetSort(bson.M{"_id": -1}).SetLimit(maxUndoLookback),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []models.Activity
+	if err := cursor.All(ctx, &recent); err != nil {
+		return nil, err
+	}
+
+	reverted, err := revertedIDs(ctx, activities, userID, entryIDs(recent))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range recent {
+		if !reverted[entry.ID] {
+			return groupEntries(ctx, activities, userID, entry.Group)
+		}
+	}
+	return nil, ErrNothingToUndo
+}
+
+// entryAccount is the user document the item of an entry lives in.
+func entryAccount(entry models.Activity) string {
+	if entry.AccountID != "" {
+		return entry.AccountID
+	}
+	return entry.UserID
+}
+
+// canEdit reports whether the user may still change an item they changed
+// in someone else's list, which takes edit rights on the lists it was in
+// before and after the change. The owner's inbox is never shared.
+func canEdit(ctx context.Context, db *mongo.Database, userID string, entry models.Activity) (bool, error) {
+	for _, doc := range []bson.M{entry.Before, entry.After} {
+		if doc == nil {
+			continue
+		}
+		listID, _ := doc["list_id"].(primitive.ObjectID)
+		if listID.IsZero() {
+			return false, nil
+		}
+		_, role, err := share.ListAccount(ctx, config.UserCollection(db), models.User{ID: userID}, listID)
+		if errors.Is(err, share.ErrNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !share.CanEdit(role) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func restore(ctx context.Context, db *mongo.Database, accountID string, entry models.Activity) error {
+	field := userArrays[entry.ItemType]
+	items := itemCollection(db, entry.ItemType)
+	users := config.UserCollection(db)
+
+	switch entry.Action {
+	case ActionCreate:
+		if _, err := items.DeleteOne(ctx, bson.M{"_id": entry.ItemID}); err != nil {
+			return err
+		}
+		_, err := users.UpdateOne(ctx, bson.M{"_id": accountID}, bson.M{"$pull": bson.M{field: bson.M{"_id": entry.ItemID}}})
+		return err
+
+	case ActionUpdate:
+		// Snapshots leave out the derived fields, so they are carried over
+		// from the item as it is now.
+		restored := bson.M{}
+		for key, value := range entry.Before {
+			restored[key] = value
+		}
+		current, err := element(ctx, db, accountID, entry.ItemType, entry.ItemID)
+		if err != nil {
+			return err
+		}
+		for _, name := range derivedFields[entry.ItemType] {
+			if value, err := current.LookupErr(name); err == nil {
+				restored[name] = value
+			}
+		}
+
+		_, err = items.ReplaceOne(ctx, bson.M{"_id": entry.ItemID}, restored, options.Replace().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+		_, err = users.UpdateOne(
+			ctx,
+			bson.M{"_id": accountID, field + "._id": entry.ItemID},
+			bson.M{"$set": bson.M{field + ".$": restored}},
+		)
+		return err
+
+	case ActionDelete:
+		_, err := items.ReplaceOne(ctx, bson.M{"_id": entry.ItemID}, entry.Before, options.Replace().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+		_, err = users.UpdateOne(ctx, bson.M{"_id": accountID}, mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{field: bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$" + field, bson.A{}}},
+				bson.A{bson.M{"$literal": entry.Before}},
+			}}}}},
+		})
+		return err
+	}
+	return ErrNotUndoable
+}
+
+// Undo reverts every entry recorded by the same request as activityID, or
+// the most recent request still standing when activityID is nil. It refuses
+// to touch anything if one of the items has changed since.
+func Undo(ctx context.Context, db *mongo.Database, userID string, activityID *primitive.ObjectID) ([]models.Activity, error) {
+	activities := config.ActivityCollection(db)
+
+	var entries []models.Activity
+	if activityID == nil {
+		var err error
+		if entries, err = latestGroup(ctx, activities, userID); err != nil {
+			return nil, err
+		}
+	} else {
+		var entry models.Activity
+		err := activities.FindOne(ctx, bson.M{"_id": *activityID, "user_id": userID}).Decode(&entry)
+		if err != nil {
+			return nil, err
+		}
+		if entries, err = groupEntries(ctx, activities, userID, entry.Group); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Action == ActionUndo {
+			return nil, ErrNotUndoable
+		}
+	}
+
+	reverted, err := revertedIDs(ctx, activities, userID, entryIDs(entries))
+	if err != nil {
+		return nil, err
+	}
+	if len(reverted) > 0 {
+		return nil, ErrAlreadyUndone
+	}
+
+	latest := make(map[primitive.ObjectID]models.Activity)
+	for _, entry := range entries {
+		latest[entry.ItemID] = entry
+		if entryAccount(entry) == userID {
+			continue
+		}
+		allowed, err := canEdit(ctx, db, userID, entry)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrNoAccess
+		}
+	}
+
+	recorder := NewRecorder(db, userID, userID)
+	current := make(map[primitive.ObjectID]bson.Raw)
+	for itemID, entry := range latest {
+		snap, err := snapshot(ctx, db, entryAccount(entry), entry.ItemType, itemID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		if hash(snap) != entry.AfterHash {
+			return nil, &ConflictError{ItemType: entry.ItemType, ItemID: itemID}
+		}
+		current[itemID] = snap
+	}
+
+	undone := make([]models.Activity, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		account := entryAccount(entry)
+		if err := restore(ctx, db, account, entry); err != nil {
+			return undone, err
+		}
+
+		before := current[entry.ItemID]
+		current[entry.ItemID] = recorder.snapshot(account, entry.ItemType, entry.ItemID)
+		if err := recorder.insert(account, entry.ItemType, entry.ItemID, before, current[entry.ItemID], ActionUndo, []primitive.ObjectID{entry.ID}); err != nil {
+			return undone, err
+		}
+		undone = append(undone, entry)
+	}
+	return undone, nil
+}
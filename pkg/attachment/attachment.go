@@ -0,0 +1,346 @@
+package attachment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"github.com/userAdityaa/todo-backend/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ItemTodo   = "todo"
+	ItemSticky = "sticky"
+)
+
+const (
+	urlTTL      = 15 * time.Minute
+	orphanGrace = 7 * 24 * time.Hour
+	sweepBatch  = 500
+)
+
+var allowedTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+// record is the server-side bookkeeping for a blob. The metadata clients see
+// lives on the todo or sticky itself; this is what ties a blob to its owner
+// so downloads can be authorised and orphans found.
+type record struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	UserID      string             `bson:"user_id"`
+	ItemType    string             `bson:"item_type"`
+	ItemID      primitive.ObjectID `bson:"item_id"`
+	Key         string             `bson:"key"`
+	Name        string             `bson:"name"`
+	ContentType string             `bson:"content_type"`
+	Size        int64              `bson:"size"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	OrphanedAt  *time.Time         `bson:"orphaned_at,omitempty"`
+}
+
+type SweepResult struct {
+	Checked  int `json:"checked"`
+	Orphaned int `json:"orphaned"`
+	Restored int `json:"restored"`
+	Deleted  int `json:"deleted"`
+}
+
+var (
+	keyOnce sync.Once
+	urlKey  []byte
+)
+
+// signingKey falls back to a random per-process key, which only means links
+// stop working after a restart or on another instance.
+func signingKey() []byte {
+	keyOnce.Do(func() {
+		if config.AttachmentURLSecret != "" {
+			urlKey = []byte(config.AttachmentURLSecret)
+			return
+		}
+		log.Println("ATTACHMENT_URL_SECRET is not set, download links will not survive a restart")
+		urlKey = make([]byte, 32)
+		if _, err := rand.Read(urlKey); err != nil {
+			panic(err)
+		}
+	})
+	return urlKey
+}
+
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := config.AttachmentCollection(db).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "item_type", Value: 1}, {Key: "item_id", Value: 1}}},
+		{Keys: bson.D{{Key: "orphaned_at", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	return err
+}
+
+func itemField(itemType string) string {
+	if itemType == ItemSticky {
+		return "sticky"
+	}
+	return "todos"
+}
+
+func itemCollection(db *mongo.Database, itemType string) *mongo.Collection {
+	if itemType == ItemSticky {
+		return config.StickyCollection(db)
+	}
+	return config.TodoCollection(db)
+}
+
+// itemAttachments returns the attachments on the user's item, and false when
+// the item does not exist.
+func itemAttachments(user models.User, itemType string, itemID primitive.ObjectID) ([]models.Attachment, bool) {
+	if itemType == ItemSticky {
+		for _, sticky := range user.Stick {
+			if sticky.ID == itemID {
+				return sticky.Attachments, true
+			}
+		}
+		return nil, false
+	}
+	for _, todo := range user.Todo {
+		if todo.ID == itemID {
+			return todo.Attachments, true
+		}
+	}
+	return nil, false
+}
+
+// itemAccount returns the account that holds the item, which is where its
+// attachments are written. A todo in a list shared with the user lives in
+// the list owner's account; stickies are always the user's own. Viewers
+// only get through when write is false. It writes the error response itself.
+func itemAccount(w http.ResponseWriter, userCollection *mongo.Collection, user models.User, itemType string, itemID primitive.ObjectID, write bool) (models.User, bool) {
+	if itemType == ItemSticky {
+		return user, true
+	}
+
+	account, role, err := share.TodoAccount(context.TODO(), userCollection, user, itemID)
+	if errors.Is(err, share.ErrNotFound) {
+		http.Error(w, itemLabel(itemType)+" not found", http.StatusNotFound)
+		return models.User{}, false
+	}
+	if err != nil {
+		log.Println("Error resolving todo:", err)
+		http.Error(w, "Failed to resolve todo", http.StatusInternalServerError)
+		return models.User{}, false
+	}
+	if write && !share.CanEdit(role) {
+		http.Error(w, "You have read-only access to this list", http.StatusForbidden)
+		return models.User{}, false
+	}
+	return account, true
+}
+
+// errLimit is returned by pushAttachment when the item already holds
+// maxPerItem attachments.
+var errLimit = errors.New("attachment limit reached")
+
+// pushAttachment adds one attachment in both places. The limit is part of
+// the update filter rather than checked against an earlier read, so parallel
+// uploads to the same item can neither drop each other's entries nor get
+// past maxPerItem between them.
+func pushAttachment(ctx context.Context, db *mongo.Database, userID string, itemType string, itemID primitive.ObjectID, attachment models.Attachment) error {
+	field := itemField(itemType)
+	users := config.UserCollection(db)
+	items := itemCollection(db, itemType)
+	full := fmt.Sprintf("attachments.%d", maxPerItem-1)
+
+	// Items saved without attachments hold null rather than an empty array,
+	// and $push refuses to append to null.
+	_, err := users.UpdateOne(
+		ctx,
+		bson.M{"_id": userID, field: bson.M{"$elemMatch": bson.M{"_id": itemID, "attachments": nil}}},
+		bson.M{"$set": bson.M{field + ".$.attachments": []models.Attachment{}}},
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := items.UpdateOne(ctx, bson.M{"_id": itemID, "attachments": nil}, bson.M{"$set": bson.M{"attachments": []models.Attachment{}}}); err != nil {
+		return err
+	}
+
+	result, err := users.UpdateOne(
+		ctx,
+		bson.M{"_id": userID, field: bson.M{"$elemMatch": bson.M{"_id": itemID, full: bson.M{"$exists": false}}}},
+		bson.M{"$push": bson.M{field + ".$.attachments": attachment}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := users.CountDocuments(ctx, bson.M{"_id": userID, field + "._id": itemID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return mongo.ErrNoDocuments
+		}
+		return errLimit
+	}
+
+	_, err = items.UpdateOne(
+		ctx,
+		bson.M{"_id": itemID},
+		bson.M{"$push": bson.M{"attachments": attachment}},
+	)
+	return err
+}
+
+// pullAttachment removes one attachment in both places, leaving any added
+// in the meantime alone.
+func pullAttachment(ctx context.Context, db *mongo.Database, userID string, itemType string, itemID primitive.ObjectID, attachmentID primitive.ObjectID) error {
+	field := itemField(itemType)
+	result, err := config.UserCollection(db).UpdateOne(
+		ctx,
+		bson.M{"_id": userID, field + "._id": itemID},
+		bson.M{"$pull": bson.M{field + ".$.attachments": bson.M{"_id": attachmentID}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	_, err = itemCollection(db, itemType).UpdateOne(
+		ctx,
+		bson.M{"_id": itemID},
+		bson.M{"$pull": bson.M{"attachments": bson.M{"_id": attachmentID}}},
+	)
+	return err
+}
+
+func sign(id primitive.ObjectID, expires int64) string {
+	mac := hmac.New(sha256.New, signingKey())
+	fmt.Fprintf(mac, "%s:%d", id.Hex(), expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DownloadURL returns a short-lived link that works without an Authorization
+// header, so it can be used directly in <img> tags and browser downloads.
+func DownloadURL(id primitive.ObjectID, now time.Time) (string, time.Time) {
+	expires := now.Add(urlTTL).Truncate(time.Second)
+	return fmt.Sprintf("/attachments/%s/download?expires=%d&sig=%s", id.Hex(), expires.Unix(), sign(id, expires.Unix())), expires
+}
+
+func verify(id primitive.ObjectID, expiresParam string, sig string, now time.Time) bool {
+	expires, err := strconv.ParseInt(expiresParam, 10, 64)
+	if err != nil || now.Unix() > expires {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(sign(id, expires)), []byte(sig)) == 1
+}
+
+// Sweep removes blobs whose todo or sticky no longer references them. An
+// unreferenced attachment is first marked and only deleted once it has stayed
+// unreferenced for the grace period, so undoing a delete within that window
+// brings the item back with working attachments.
+func Sweep(ctx context.Context, db *mongo.Database, store storage.Store, now time.Time) (SweepResult, error) {
+	var result SweepResult
+	records := config.AttachmentCollection(db)
+	users := config.UserCollection(db)
+
+	cursor, err := records.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"user_id": 1}).SetBatchSize(sweepBatch))
+	if err != nil {
+		return result, err
+	}
+	defer cursor.Close(ctx)
+
+	var user models.User
+	loaded := ""
+	for cursor.Next(ctx) {
+		var rec record
+		if err := cursor.Decode(&rec); err != nil {
+			return result, err
+		}
+		result.Checked++
+
+		if rec.UserID != loaded {
+			user = models.User{}
+			err := users.FindOne(
+				ctx,
+				bson.M{"_id": rec.UserID},
+				options.FindOne().SetProjection(bson.M{"todos._id": 1, "todos.attachments._id": 1, "sticky._id": 1, "sticky.attachments._id": 1}),
+			).Decode(&user)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return result, err
+			}
+			loaded = rec.UserID
+		}
+
+		referenced := false
+		attachments, _ := itemAttachments(user, rec.ItemType, rec.ItemID)
+		for _, attachment := range attachments {
+			if attachment.ID == rec.ID {
+				referenced = true
+			}
+		}
+
+		switch {
+		case referenced && rec.OrphanedAt != nil:
+			if _, err := records.UpdateByID(ctx, rec.ID, bson.M{"$unset": bson.M{"orphaned_at": ""}}); err != nil {
+				return result, err
+			}
+			result.Restored++
+		case referenced:
+		case rec.OrphanedAt == nil:
+			if _, err := records.UpdateByID(ctx, rec.ID, bson.M{"$set": bson.M{"orphaned_at": now}}); err != nil {
+				return result, err
+			}
+			result.Orphaned++
+		case now.Sub(*rec.OrphanedAt) >= orphanGrace:
+			if err := store.Delete(ctx, rec.Key); err != nil {
+				log.Println("Error deleting orphaned attachment blob:", err)
+				continue
+			}
+			if _, err := records.DeleteOne(ctx, bson.M{"_id": rec.ID}); err != nil {
+				return result, err
+			}
+			result.Deleted++
+		}
+	}
+	return result, cursor.Err()
+}
+
+func RunSweeper(ctx context.Context, db *mongo.Database, store storage.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := Sweep(ctx, db, store, time.Now()); err != nil {
+			log.Println("Attachment sweep failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,545 @@
+package attachment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/storage"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxPerItem    = 20
+	maxNameLength = 200
+	formMemory    = 1 << 20
+)
+
+func itemLabel(itemType string) string {
+	if itemType == ItemSticky {
+		return "Sticky"
+	}
+	return "Todo"
+}
+
+func cleanName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	for len(name) > maxNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+// detectType sniffs the content rather than trusting the client's header, so
+// a script renamed to .png is rejected.
+func detectType(file io.ReadSeeker) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil {
+		return "", err
+	}
+	return contentType, nil
+}
+
+func UploadAttachment(itemType string, collection *mongo.Collection, userCollection *mongo.Collection, store storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		itemID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid "+strings.ToLower(itemLabel(itemType))+" ID", http.StatusBadRequest)
+			return
+		}
+
+		user, ok = itemAccount(w, userCollection, user, itemType, itemID, true)
+		if !ok {
+			return
+		}
+
+		attachments, found := itemAttachments(user, itemType, itemID)
+		if !found {
+			http.Error(w, itemLabel(itemType)+" not found", http.StatusNotFound)
+			return
+		}
+		if len(attachments) >= maxPerItem {
+			http.Error(w, "Attachment limit reached for this "+strings.ToLower(itemLabel(itemType)), http.StatusConflict)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, config.AttachmentMaxBytes+formMemory)
+		if err := r.ParseMultipartForm(formMemory); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "A file is required in the \"file\" field", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		if header.Size > config.AttachmentMaxBytes {
+			http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if header.Size == 0 {
+			http.Error(w, "File is empty", http.StatusBadRequest)
+			return
+		}
+
+		contentType, err := detectType(file)
+		if err != nil {
+			log.Println("Error reading upload:", err)
+			http.Error(w, "Failed to read file", http.StatusBadRequest)
+			return
+		}
+		if !allowedTypes[contentType] {
+			http.Error(w, "Unsupported file type "+contentType+", expected an image, PDF or plain text", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		now := time.Now()
+		rec := record{
+			ID:          primitive.NewObjectID(),
+			UserID:      user.ID,
+			ItemType:    itemType,
+			ItemID:      itemID,
+			Name:        cleanName(header.Filename),
+			ContentType: contentType,
+			Size:        header.Size,
+			CreatedAt:   now,
+		}
+		rec.Key = "attachments/" + rec.ID.Hex()
+
+		if err := store.Put(context.TODO(), rec.Key, file, rec.Size, rec.ContentType); err != nil {
+			log.Println("Error storing attachment:", err)
+			http.Error(w, "Failed to store attachment", http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := collection.InsertOne(context.TODO(), rec); err != nil {
+			log.Println("Error inserting attachment:", err)
+			store.Delete(context.TODO(), rec.Key)
+			http.Error(w, "Failed to save attachment", http.StatusInternalServerError)
+			return
+		}
+
+		attachment := models.Attachment{
+			ID:          rec.ID,
+			Name:        rec.Name,
+			ContentType: rec.ContentType,
+			Size:        rec.Size,
+			CreatedAt:   rec.CreatedAt,
+		}
+		if err := pushAttachment(context.TODO(), collection.Database(), user.ID, itemType, itemID, attachment); err != nil {
+			collection.DeleteOne(context.TODO(), bson.M{"_id": rec.ID})
+			store.Delete(context.TODO(), rec.Key)
+			switch {
+			case errors.Is(err, errLimit):
+				http.Error(w, "Attachment limit reached for this "+strings.ToLower(itemLabel(itemType)), http.StatusConflict)
+			case errors.Is(err, mongo.ErrNoDocuments):
+				http.Error(w, itemLabel(itemType)+" not found", http.StatusNotFound)
+			default:
+				log.Println("Error updating attachments:", err)
+				http.Error(w, "Failed to save attachment", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		url, expires := DownloadURL(rec.ID, now)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":    "Attachment uploaded successfully",
+			"attachment": attachment,
+			"url":        url,
+			"expires_at": expires,
+		})
+	}
+}
+
+func GetAttachments(itemType string, collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		itemID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid "+strings.ToLower(itemLabel(itemType))+" ID", http.StatusBadRequest)
+			return
+		}
+
+		user, ok = itemAccount(w, userCollection, user, itemType, itemID, false)
+		if !ok {
+			return
+		}
+
+		attachments, found := itemAttachments(user, itemType, itemID)
+		if !found {
+			http.Error(w, itemLabel(itemType)+" not found", http.StatusNotFound)
+			return
+		}
+
+		now := time.Now()
+		type attachmentWithURL struct {
+			models.Attachment
+			URL       string    `json:"url"`
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		response := make([]attachmentWithURL, 0, len(attachments))
+		for _, attachment := range attachments {
+			url, expires := DownloadURL(attachment.ID, now)
+			response = append(response, attachmentWithURL{Attachment: attachment, URL: url, ExpiresAt: expires})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func GetAttachmentURL(itemType string, collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		itemID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid "+strings.ToLower(itemLabel(itemType))+" ID", http.StatusBadRequest)
+			return
+		}
+
+		attachmentID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "attachmentId"))
+		if err != nil {
+			http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
+			return
+		}
+
+		user, ok = itemAccount(w, userCollection, user, itemType, itemID, false)
+		if !ok {
+			return
+		}
+
+		attachments, found := itemAttachments(user, itemType, itemID)
+		if !found {
+			http.Error(w, itemLabel(itemType)+" not found", http.StatusNotFound)
+			return
+		}
+
+		for _, attachment := range attachments {
+			if attachment.ID != attachmentID {
+				continue
+			}
+
+			url, expires := DownloadURL(attachment.ID, time.Now())
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"url":        url,
+				"expires_at": expires,
+			})
+			return
+		}
+
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+	}
+}
+
+func DeleteAttachment(itemType string, collection *mongo.Collection, userCollection *mongo.Collection, store storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		itemID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid "+strings.ToLower(itemLabel(itemType))+" ID", http.StatusBadRequest)
+			return
+		}
+
+		attachmentID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "attachmentId"))
+		if err != nil {
+			http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
+			return
+		}
+
+		user, ok = itemAccount(w, userCollection, user, itemType, itemID, true)
+		if !ok {
+			return
+		}
+
+		attachments, found := itemAttachments(user, itemType, itemID)
+		if !found {
+			http.Error(w, itemLabel(itemType)+" not found", http.StatusNotFound)
+			return
+		}
+
+		attached := false
+		for _, attachment := range attachments {
+			if attachment.ID == attachmentID {
+				attached = true
+			}
+		}
+		if !attached {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		}
+
+		if err := pullAttachment(context.TODO(), collection.Database(), user.ID, itemType, itemID, attachmentID); err != nil {
+			log.Println("Error updating attachments:", err)
+			http.Error(w, "Failed to delete attachment", http.StatusInternalServerError)
+			return
+		}
+
+		// The metadata is already gone, so a failure here only leaves the blob
+		// for the sweeper to collect.
+		var rec record
+		err = collection.FindOneAndDelete(context.TODO(), bson.M{"_id": attachmentID, "user_id": user.ID}).Decode(&rec)
+		if err == nil {
+			if err := store.Delete(context.TODO(), rec.Key); err != nil {
+				log.Println("Error deleting attachment blob:", err)
+			}
+		} else if err != mongo.ErrNoDocuments {
+			log.Println("Error deleting attachment record:", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Attachment deleted successfully",
+		})
+	}
+}
+
+// DownloadAttachment serves a blob to anyone holding a valid signed link; the
+// link itself is only handed out to the owner.
+func DownloadAttachment(collection *mongo.Collection, store storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attachmentID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid attachment ID", http.StatusBadRequest)
+			return
+		}
+
+		if !verify(attachmentID, r.URL.Query().Get("expires"), r.URL.Query().Get("sig"), time.Now()) {
+			http.Error(w, "Download link is invalid or has expired", http.StatusForbidden)
+			return
+		}
+
+		var rec record
+		err = collection.FindOne(context.TODO(), bson.M{"_id": attachmentID}).Decode(&rec)
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error fetching attachment:", err)
+			http.Error(w, "Failed to fetch attachment", http.StatusInternalServerError)
+			return
+		}
+
+		body, err := store.Get(r.Context(), rec.Key)
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error reading attachment blob:", err)
+			http.Error(w, "Failed to fetch attachment", http.StatusInternalServerError)
+			return
+		}
+		defer body.Close()
+
+		disposition := "attachment"
+		if strings.HasPrefix(rec.ContentType, "image/") {
+			disposition = "inline"
+		}
+
+		if header := mime.FormatMediaType(disposition, map[string]string{"filename": rec.Name}); header != "" {
+			disposition = header
+		}
+
+		w.Header().Set("Content-Type", rec.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(rec.Size, 10))
+		w.Header().Set("Content-Disposition", disposition)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Security-Policy", "sandbox")
+		w.Header().Set("Cache-Control", "private, max-age=900")
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, body); err != nil {
+			log.Println("Error streaming attachment:", err)
+		}
+	}
+}
+
+func CronSweep(collection *mongo.Collection, store storage.Store, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !utils.CheckCronSecret(w, r, secret) {
+			return
+		}
+
+		result, err := Sweep(r.Context(), collection.Database(), store, time.Now())
+		if err != nil {
+			log.Println("Attachment sweep failed:", err)
+			http.Error(w, "Failed to sweep attachments", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/userAdityaa/todo-backend/models"
 	"github.com/userAdityaa/todo-backend/utils"
@@ -128,3 +129,66 @@ func GetUserDetailsHandler(database *mongo.Database) http.HandlerFunc {
 		}
 	}
 }
+
+func UpdateTimeZoneHandler(database *mongo.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Authorization header missing", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var request struct {
+			TimeZone string `json:"timezone"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := time.LoadLocation(request.TimeZone); err != nil || request.TimeZone == "" {
+			http.Error(w, "Invalid IANA timezone", http.StatusBadRequest)
+			return
+		}
+
+		collection := database.Collection("user")
+		result, err := collection.UpdateOne(
+			context.Background(),
+			bson.M{"email": email},
+			bson.M{"$set": bson.M{"timezone": request.TimeZone}},
+		)
+		if err != nil {
+			http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if result.MatchedCount == 0 {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Timezone updated successfully",
+			"timezone": request.TimeZone,
+		})
+	}
+}
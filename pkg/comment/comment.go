@@ -0,0 +1,155 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxBodyLength = 10000
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := config.CommentCollection(db).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "todo_id", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "mentions.user_id", Value: 1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+// normalizeBody trims the markdown source and enforces the length limit. The
+// body is stored as written; rendering is left to the client.
+func normalizeBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", errors.New("comment body is required")
+	}
+	if utf8.RuneCountInString(body) > maxBodyLength {
+		return "", errors.New("comment body is too long")
+	}
+	return body, nil
+}
+
+func findTodo(todos []models.Todo, id primitive.ObjectID) int {
+	for i, todo := range todos {
+		if todo.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// todoAccount finds the todo in the user's own document or in a list shared
+// with them and returns the account it lives in, which is the owner every
+// comment on it is filed under. Viewers can read comments but not write
+// them. It writes the error response itself.
+func todoAccount(w http.ResponseWriter, userCollection *mongo.Collection, user models.User, todoID primitive.ObjectID, write bool) (models.User, int, bool) {
+	account, role, err := share.TodoAccount(context.TODO(), userCollection, user, todoID)
+	if errors.Is(err, share.ErrNotFound) {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return models.User{}, -1, false
+	}
+	if err != nil {
+		log.Println("Error resolving todo:", err)
+		http.Error(w, "Failed to resolve todo", http.StatusInternalServerError)
+		return models.User{}, -1, false
+	}
+	if write && !share.CanEdit(role) {
+		http.Error(w, "You have read-only access to this list", http.StatusForbidden)
+		return models.User{}, -1, false
+	}
+	return account, findTodo(account.Todo, todoID), true
+}
+
+// participants are the users who can see a todo and so can be mentioned on
+// it: its owner and, for a todo in a shared list, the list's members.
+func participants(ctx context.Context, userCollection *mongo.Collection, owner models.User, todo models.Todo) ([]models.User, error) {
+	users := []models.User{owner}
+	if todo.ListID.IsZero() {
+		return users, nil
+	}
+
+	members, err := share.Members(ctx, userCollection.Database(), todo.ListID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		users = append(users, models.User{ID: member.UserID, Name: member.Name, Email: member.Email})
+	}
+	return users, nil
+}
+
+func handles(user models.User) []string {
+	email := strings.ToLower(user.Email)
+	handles := []string{email}
+	if at := strings.IndexByte(email, '@'); at > 0 {
+		handles = append(handles, email[:at])
+	}
+	if name := strings.ToLower(strings.Join(strings.Fields(user.Name), "")); name != "" {
+		handles = append(handles, name)
+	}
+	return handles
+}
+
+// Mentions resolves @handles in body against the given users. A handle is a
+// full email, the part of the email before the @, or the user's name with the
+// spaces removed; anything that matches no one is left as plain text.
+func Mentions(body string, users []models.User) []models.Mention {
+	mentions := make([]models.Mention, 0)
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		for _, user := range users {
+			if seen[user.ID] {
+				continue
+			}
+			for _, candidate := range handles(user) {
+				if candidate == handle {
+					seen[user.ID] = true
+					mentions = append(mentions, models.Mention{UserID: user.ID, Name: user.Name, Email: user.Email})
+					break
+				}
+			}
+		}
+	}
+	return mentions
+}
+
+// RemoveForTodos deletes the comments of deleted todos, so they stop
+// showing up among the mentions.
+func RemoveForTodos(ctx context.Context, db *mongo.Database, todoIDs ...primitive.ObjectID) error {
+	_, err := config.CommentCollection(db).DeleteMany(ctx, bson.M{"todo_id": bson.M{"$in": todoIDs}})
+	return err
+}
+
+// adjustCount keeps the denormalised comment count on the todo in step in
+// both the owner's document and the todo collection.
+func adjustCount(ctx context.Context, collection *mongo.Collection, userCollection *mongo.Collection, ownerID string, todoID primitive.ObjectID, delta int) error {
+	_, err := userCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": ownerID, "todos._id": todoID},
+		bson.M{"$inc": bson.M{"todos.$.comment_count": delta}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = config.TodoCollection(collection.Database()).UpdateOne(
+		ctx,
+		bson.M{"_id": todoID},
+		bson.M{"$inc": bson.M{"comment_count": delta}},
+	)
+	return err
+}
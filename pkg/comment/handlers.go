@@ -0,0 +1,457 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultMentionLimit = 50
+	maxMentionLimit     = 200
+)
+
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+func GetComments(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+			return
+		}
+
+		account, _, ok := todoAccount(w, userCollection, user, todoID, false)
+		if !ok {
+			return
+		}
+
+		cursor, err := collection.Find(
+			context.TODO(),
+			bson.M{"owner_id": account.ID, "todo_id": todoID},
+			options.Find().SetSort(bson.M{"_id": 1}),
+		)
+		if err != nil {
+			log.Println("Error fetching comments:", err)
+			http.Error(w, "Failed to fetch comments", http.StatusInternalServerError)
+			return
+		}
+
+		comments := make([]models.Comment, 0)
+		if err := cursor.All(context.TODO(), &comments); err != nil {
+			log.Println("Error decoding comments:", err)
+			http.Error(w, "Failed to fetch comments", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(comments)
+	}
+}
+
+func CreateComment(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+			return
+		}
+
+		account, index, ok := todoAccount(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		var request commentRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		body, err := normalizeBody(request.Body)
+		if err != nil {
+			http.Error(w, "Invalid comment: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		users, err := participants(context.TODO(), userCollection, account, account.Todo[index])
+		if err != nil {
+			log.Println("Error resolving participants:", err)
+			http.Error(w, "Failed to create comment", http.StatusInternalServerError)
+			return
+		}
+
+		comment := models.Comment{
+			ID:            primitive.NewObjectID(),
+			TodoID:        todoID,
+			OwnerID:       account.ID,
+			AuthorID:      user.ID,
+			AuthorName:    user.Name,
+			AuthorPicture: user.Picture,
+			Body:          body,
+			Mentions:      Mentions(body, users),
+			CreatedAt:     time.Now(),
+		}
+
+		if _, err := collection.InsertOne(context.TODO(), comment); err != nil {
+			log.Println("Error inserting comment:", err)
+			http.Error(w, "Failed to create comment", http.StatusInternalServerError)
+			return
+		}
+
+		if err := adjustCount(context.TODO(), collection, userCollection, comment.OwnerID, todoID, 1); err != nil {
+			log.Println("Error updating comment count:", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Comment created successfully",
+			"comment": comment,
+		})
+	}
+}
+
+func UpdateComment(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+			return
+		}
+
+		commentID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "commentId"))
+		if err != nil {
+			http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+			return
+		}
+
+		account, index, ok := todoAccount(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		var request commentRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		body, err := normalizeBody(request.Body)
+		if err != nil {
+			http.Error(w, "Invalid comment: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var comment models.Comment
+		err = collection.FindOne(context.TODO(), bson.M{"_id": commentID, "owner_id": account.ID, "todo_id": todoID}).Decode(&comment)
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error fetching comment:", err)
+			http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+			return
+		}
+		if comment.AuthorID != user.ID {
+			http.Error(w, "Only the author can edit a comment", http.StatusForbidden)
+			return
+		}
+
+		users, err := participants(context.TODO(), userCollection, account, account.Todo[index])
+		if err != nil {
+			log.Println("Error resolving participants:", err)
+			http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+			return
+		}
+
+		editedAt := time.Now()
+		comment.Body = body
+		comment.Mentions = Mentions(body, users)
+		comment.EditedAt = &editedAt
+
+		_, err = collection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": commentID},
+			bson.M{"$set": bson.M{"body": comment.Body, "mentions": comment.Mentions, "edited_at": editedAt}},
+		)
+		if err != nil {
+			log.Println("Error updating comment:", err)
+			http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Comment updated successfully",
+			"comment": comment,
+		})
+	}
+}
+
+func DeleteComment(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+			return
+		}
+
+		commentID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "commentId"))
+		if err != nil {
+			http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+			return
+		}
+
+		account, _, ok := todoAccount(w, userCollection, user, todoID, false)
+		if !ok {
+			return
+		}
+
+		var comment models.Comment
+		err = collection.FindOne(context.TODO(), bson.M{"_id": commentID, "owner_id": account.ID, "todo_id": todoID}).Decode(&comment)
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error fetching comment:", err)
+			http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+			return
+		}
+
+		// The todo's owner may remove any comment on it, everyone else only
+		// their own.
+		if comment.AuthorID != user.ID && comment.OwnerID != user.ID {
+			http.Error(w, "Only the author or the todo's owner can delete a comment", http.StatusForbidden)
+			return
+		}
+
+		result, err := collection.DeleteOne(context.TODO(), bson.M{"_id": commentID})
+		if err != nil {
+			log.Println("Error deleting comment:", err)
+			http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+			return
+		}
+		if result.DeletedCount == 0 {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+
+		if err := adjustCount(context.TODO(), collection, userCollection, comment.OwnerID, todoID, -1); err != nil {
+			log.Println("Error updating comment count:", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Comment deleted successfully",
+		})
+	}
+}
+
+func GetMentions(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		limit := defaultMentionLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxMentionLimit {
+				http.Error(w, "Invalid limit, expected 1 to 200", http.StatusBadRequest)
+				return
+			}
+		}
+
+		cursor, err := collection.Find(
+			context.TODO(),
+			bson.M{"mentions.user_id": user.ID},
+			options.Find().SetSort(bson.M{"_id": -1}).SetLimit(int64(limit)),
+		)
+		if err != nil {
+			log.Println("Error fetching mentions:", err)
+			http.Error(w, "Failed to fetch mentions", http.StatusInternalServerError)
+			return
+		}
+
+		comments := make([]models.Comment, 0)
+		if err := cursor.All(context.TODO(), &comments); err != nil {
+			log.Println("Error decoding mentions:", err)
+			http.Error(w, "Failed to fetch mentions", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(comments)
+	}
+}
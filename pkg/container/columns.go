@@ -0,0 +1,502 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/todo"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxColumns          = 20
+	maxColumnNameLength = 50
+)
+
+func findList(lists []models.List, id primitive.ObjectID) int {
+	for i, list := range lists {
+		if list.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func findColumn(columns []models.Column, id primitive.ObjectID) int {
+	for i, column := range columns {
+		if column.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func normalizeColumn(column *models.Column, columns []models.Column) error {
+	column.Name = strings.TrimSpace(column.Name)
+	if column.Name == "" {
+		return errors.New("column name is required")
+	}
+	if utf8.RuneCountInString(column.Name) > maxColumnNameLength {
+		return errors.New("column name is too long")
+	}
+	if column.WIPLimit < 0 {
+		return errors.New("WIP limit cannot be negative")
+	}
+	for _, other := range columns {
+		if other.ID != column.ID && strings.EqualFold(other.Name, column.Name) {
+			return errors.New("a column with this name already exists")
+		}
+	}
+	return nil
+}
+
+// normalizeColumns validates the columns sent with a new list and gives them
+// fresh IDs.
+func normalizeColumns(columns []models.Column) ([]models.Column, error) {
+	if len(columns) > maxColumns {
+		return nil, errors.New("too many columns")
+	}
+	normalized := make([]models.Column, 0, len(columns))
+	for _, column := range columns {
+		column.ID = primitive.NewObjectID()
+		if err := normalizeColumn(&column, normalized); err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, column)
+	}
+	return normalized, nil
+}
+
+func setColumns(listCollection *mongo.Collection, userCollection *mongo.Collection, userID string, listID primitive.ObjectID, columns []models.Column) error {
+	_, err := listCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": listID},
+		bson.M{"$set": bson.M{"columns": columns}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": userID, "list._id": listID},
+		bson.M{"$set": bson.M{"list.$.columns": columns}},
+	)
+	return err
+}
+
+func AddColumn(listCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findList(user.List, listID)
+		if index == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		columns := user.List[index].Columns
+
+		var request struct {
+			Name     string `json:"name"`
+			WIPLimit int    `json:"wip_limit"`
+			Position *int   `json:"position,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if len(columns) >= maxColumns {
+			http.Error(w, "This list already has the maximum number of columns", http.StatusConflict)
+			return
+		}
+
+		column := models.Column{ID: primitive.NewObjectID(), Name: request.Name, WIPLimit: request.WIPLimit}
+		if err := normalizeColumn(&column, columns); err != nil {
+			http.Error(w, "Invalid column: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		position := len(columns)
+		if request.Position != nil {
+			if *request.Position < 0 || *request.Position > len(columns) {
+				http.Error(w, "Position is out of range", http.StatusBadRequest)
+				return
+			}
+			position = *request.Position
+		}
+
+		updated := make([]models.Column, 0, len(columns)+1)
+		updated = append(updated, columns[:position]...)
+		updated = append(updated, column)
+		updated = append(updated, columns[position:]...)
+
+		if err := setColumns(listCollection, userCollection, user.ID, listID, updated); err != nil {
+			log.Println("Error saving columns:", err)
+			http.Error(w, "Failed to add column", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Column added successfully",
+			"column":  column,
+			"columns": updated,
+		})
+	}
+}
+
+func UpdateColumn(listCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		columnID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "columnId"))
+		if err != nil {
+			http.Error(w, "Invalid column ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findList(user.List, listID)
+		if index == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		columns := user.List[index].Columns
+
+		position := findColumn(columns, columnID)
+		if position == -1 {
+			http.Error(w, "Column not found", http.StatusNotFound)
+			return
+		}
+
+		var request struct {
+			Name     *string `json:"name,omitempty"`
+			WIPLimit *int    `json:"wip_limit,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		column := columns[position]
+		if request.Name != nil {
+			column.Name = *request.Name
+		}
+		if request.WIPLimit != nil {
+			column.WIPLimit = *request.WIPLimit
+		}
+		if err := normalizeColumn(&column, columns); err != nil {
+			http.Error(w, "Invalid column: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		columns[position] = column
+
+		if err := setColumns(listCollection, userCollection, user.ID, listID, columns); err != nil {
+			log.Println("Error saving columns:", err)
+			http.Error(w, "Failed to update column", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Column updated successfully",
+			"column":  column,
+		})
+	}
+}
+
+func ReorderColumns(listCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findList(user.List, listID)
+		if index == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		columns := user.List[index].Columns
+
+		var request struct {
+			IDs []primitive.ObjectID `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if len(request.IDs) != len(columns) {
+			http.Error(w, "ids must list every column of the list exactly once", http.StatusBadRequest)
+			return
+		}
+
+		reordered := make([]models.Column, 0, len(columns))
+		seen := make(map[primitive.ObjectID]bool)
+		for _, id := range request.IDs {
+			position := findColumn(columns, id)
+			if position == -1 || seen[id] {
+				http.Error(w, "ids must list every column of the list exactly once", http.StatusBadRequest)
+				return
+			}
+			seen[id] = true
+			reordered = append(reordered, columns[position])
+		}
+
+		if err := setColumns(listCollection, userCollection, user.ID, listID, reordered); err != nil {
+			log.Println("Error saving columns:", err)
+			http.Error(w, "Failed to reorder columns", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Columns reordered successfully",
+			"columns": reordered,
+		})
+	}
+}
+
+// DeleteColumn moves the column's todos to ?move_to, or leaves them
+// unassigned so the board shows them in the first column. Either way the
+// column they end up in has to have room for them under its WIP limit.
+func DeleteColumn(listCollection *mongo.Collection, userCollection *mongo.Collection, todoCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		columnID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "columnId"))
+		if err != nil {
+			http.Error(w, "Invalid column ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findList(user.List, listID)
+		if index == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		columns := user.List[index].Columns
+
+		position := findColumn(columns, columnID)
+		if position == -1 {
+			http.Error(w, "Column not found", http.StatusNotFound)
+			return
+		}
+
+		target := primitive.NilObjectID
+		if value := r.URL.Query().Get("move_to"); value != "" {
+			target, err = primitive.ObjectIDFromHex(value)
+			if err != nil || target == columnID || findColumn(columns, target) == -1 {
+				http.Error(w, "move_to must be another column of this list", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if err := todo.CheckColumnDeletion(user.Todo, user.List[index], columnID, target); err != nil {
+			http.Error(w, "Cannot delete column: "+err.Error(), http.StatusConflict)
+			return
+		}
+
+		remaining := append(append([]models.Column{}, columns[:position]...), columns[position+1:]...)
+
+		moved := 0
+		for _, todo := range user.Todo {
+			if todo.ListID == listID && todo.ColumnID == columnID {
+				moved++
+			}
+		}
+
+		if moved > 0 {
+			_, err = todoCollection.UpdateMany(
+				context.TODO(),
+				bson.M{"list_id": listID, "column_id": columnID},
+				bson.M{"$set": bson.M{"column_id": target}},
+			)
+			if err != nil {
+				log.Println("Error moving column todos:", err)
+				http.Error(w, "Failed to delete column", http.StatusInternalServerError)
+				return
+			}
+
+			_, err = userCollection.UpdateOne(
+				context.TODO(),
+				bson.M{"_id": user.ID},
+				bson.M{"$set": bson.M{"todos.$[todo].column_id": target}},
+				options.Update().SetArrayFilters(options.ArrayFilters{
+					Filters: []interface{}{bson.M{"todo.list_id": listID, "todo.column_id": columnID}},
+				}),
+			)
+			if err != nil {
+				log.Println("Error moving column todos:", err)
+				http.Error(w, "Failed to delete column", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := setColumns(listCollection, userCollection, user.ID, listID, remaining); err != nil {
+			log.Println("Error saving columns:", err)
+			http.Error(w, "Failed to delete column", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Column deleted successfully",
+			"affected": moved,
+			"columns":  remaining,
+		})
+	}
+}
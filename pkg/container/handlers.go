@@ -3,17 +3,56 @@ package container
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/pkg/comment"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"github.com/userAdityaa/todo-backend/pkg/todo"
 	"github.com/userAdityaa/todo-backend/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const maxIconLength = 32
+
+type listCounts struct {
+	Total    int `json:"total"`
+	Open     int `json:"open"`
+	Done     int `json:"done"`
+	Overdue  int `json:"overdue"`
+	DueToday int `json:"due_today"`
+	Blocked  int `json:"blocked"`
+}
+
+type listDetail struct {
+	models.List
+	Todos  []models.Todo `json:"todos"`
+	Counts listCounts    `json:"counts"`
+}
+
+// nextPosition places a new list after every existing one.
+func nextPosition(lists []models.List) int {
+	position := 0
+	for _, list := range lists {
+		if list.Position >= position {
+			position = list.Position + 1
+		}
+	}
+	return position
+}
+
 func CreateList(listCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -60,6 +99,19 @@ func CreateList(listCollection *mongo.Collection, userCollection *mongo.Collecti
 			return
 		}
 
+		newList.Columns, err = normalizeColumns(newList.Columns)
+		if err != nil {
+			http.Error(w, "Invalid columns: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		newList.Icon = strings.TrimSpace(newList.Icon)
+		if utf8.RuneCountInString(newList.Icon) > maxIconLength {
+			http.Error(w, "List icon is too long", http.StatusBadRequest)
+			return
+		}
+		newList.Position = nextPosition(user.List)
+
 		_, err = listCollection.InsertOne(context.TODO(), newList)
 		if err != nil {
 			http.Error(w, "Failed to create list", http.StatusInternalServerError)
@@ -93,7 +145,7 @@ func CreateList(listCollection *mongo.Collection, userCollection *mongo.Collecti
 	}
 }
 
-func DeleteList(listCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+func DeleteList(listCollection *mongo.Collection, userCollection *mongo.Collection, todoCollection *mongo.Collection) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -123,23 +175,75 @@ func DeleteList(listCollection *mongo.Collection, userCollection *mongo.Collecti
 			return
 		}
 
-		var deleteRequest struct {
-			ID primitive.ObjectID `json:"id"`
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
 		}
 
-		if err := json.NewDecoder(r.Body).Decode(&deleteRequest); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = "inbox"
+		}
+		if mode != "cascade" && mode != "inbox" && mode != "refuse" {
+			http.Error(w, "Mode must be one of cascade, inbox or refuse", http.StatusBadRequest)
+			return
+		}
+
+		if findList(user.List, listID) == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
 			return
 		}
 
-		if deleteRequest.ID.IsZero() {
-			http.Error(w, "Invalide list ID", http.StatusBadRequest)
+		// seriesIDs are the recurring todos elsewhere whose next occurrence
+		// would be spawned into this list.
+		var todoIDs, seriesIDs []primitive.ObjectID
+		for _, todo := range user.Todo {
+			if todo.ListID == listID {
+				todoIDs = append(todoIDs, todo.ID)
+			} else if todo.Recurrence != nil && todo.Recurrence.Template != nil && todo.Recurrence.Template.ListID == listID {
+				seriesIDs = append(seriesIDs, todo.ID)
+			}
+		}
+
+		if mode == "refuse" && len(todoIDs) > 0 {
+			http.Error(w, "List still has todos", http.StatusConflict)
+			return
+		}
+
+		recorder := activity.NewRecorder(todoCollection.Database(), user.ID, user.ID)
+		recorder.Track(activity.ItemTodo, todoIDs...)
+		recorder.Track(activity.ItemTodo, seriesIDs...)
+		if mode == "cascade" {
+			recorder.Track(activity.ItemTodo, dependentsOutside(user.Todo, listID, todoIDs)...)
+		}
+
+		if len(todoIDs) > 0 {
+			switch mode {
+			case "cascade":
+				if err := deleteListTodos(todoCollection, userCollection, user.ID, listID, todoIDs); err != nil {
+					log.Println("Error deleting list todos:", err)
+					http.Error(w, "Error Deleting List", http.StatusInternalServerError)
+					return
+				}
+			case "inbox":
+				if err := moveListTodosToInbox(todoCollection, userCollection, user.ID, listID, todoIDs); err != nil {
+					log.Println("Error moving list todos:", err)
+					http.Error(w, "Error Deleting List", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		if err := moveSeriesToInbox(todoCollection, userCollection, user.ID, listID); err != nil {
+			log.Println("Error moving recurring todos:", err)
+			http.Error(w, "Error Deleting List", http.StatusInternalServerError)
 			return
 		}
 
 		result, err := listCollection.DeleteOne(
 			context.TODO(),
-			bson.M{"_id": deleteRequest.ID},
+			bson.M{"_id": listID},
 		)
 		if err != nil {
 			http.Error(w, "Error Deleting List", http.StatusInternalServerError)
@@ -147,7 +251,7 @@ func DeleteList(listCollection *mongo.Collection, userCollection *mongo.Collecti
 		}
 
 		if result.DeletedCount == 0 {
-			http.Error(w, "Sticky not found", http.StatusNotFound)
+			http.Error(w, "List not found", http.StatusNotFound)
 			return
 		}
 
@@ -155,7 +259,7 @@ func DeleteList(listCollection *mongo.Collection, userCollection *mongo.Collecti
 			context.TODO(),
 			bson.M{"email": email},
 			bson.M{
-				"$pull": bson.M{"list": bson.M{"_id": deleteRequest.ID}},
+				"$pull": bson.M{"list": bson.M{"_id": listID}},
 			},
 		)
 
@@ -164,13 +268,113 @@ func DeleteList(listCollection *mongo.Collection, userCollection *mongo.Collecti
 			return
 		}
 
+		if err := share.RemoveList(context.TODO(), listCollection.Database(), listID); err != nil {
+			log.Println("Error removing list members:", err)
+		}
+		recorder.Commit()
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "List Deleted Successfully",
+			"message":  "List Deleted Successfully",
+			"mode":     mode,
+			"affected": len(todoIDs),
 		})
 	}
 }
 
+func deleteListTodos(todoCollection *mongo.Collection, userCollection *mongo.Collection, userID string, listID primitive.ObjectID, todoIDs []primitive.ObjectID) error {
+	_, err := todoCollection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": todoIDs}})
+	if err != nil {
+		return err
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": userID},
+		bson.M{"$pull": bson.M{"todos": bson.M{"list_id": listID}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := todo.RemoveDependency(todoCollection, userCollection, userID, todoIDs...); err != nil {
+		return err
+	}
+	return comment.RemoveForTodos(context.TODO(), todoCollection.Database(), todoIDs...)
+}
+
+// dependentsOutside returns the todos outside the list that are blocked by
+// one of its todos, which lose that prerequisite when the list goes.
+func dependentsOutside(todos []models.Todo, listID primitive.ObjectID, todoIDs []primitive.ObjectID) []primitive.ObjectID {
+	deleted := make(map[primitive.ObjectID]bool, len(todoIDs))
+	for _, id := range todoIDs {
+		deleted[id] = true
+	}
+
+	var dependents []primitive.ObjectID
+	for _, todo := range todos {
+		if todo.ListID == listID {
+			continue
+		}
+		for _, id := range todo.BlockedBy {
+			if deleted[id] {
+				dependents = append(dependents, todo.ID)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+func moveListTodosToInbox(todoCollection *mongo.Collection, userCollection *mongo.Collection, userID string, listID primitive.ObjectID, todoIDs []primitive.ObjectID) error {
+	// Columns belong to the deleted list, so the todos leave them too.
+	_, err := todoCollection.UpdateMany(
+		context.TODO(),
+		bson.M{"_id": bson.M{"$in": todoIDs}},
+		bson.M{"$set": bson.M{"list_id": primitive.NilObjectID, "column_id": primitive.NilObjectID}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{
+			"todos.$[todo].list_id":   primitive.NilObjectID,
+			"todos.$[todo].column_id": primitive.NilObjectID,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"todo.list_id": listID}},
+		}),
+	)
+	return err
+}
+
+// moveSeriesToInbox points the templates of recurring todos at the inbox
+// when their list is deleted, whichever mode the list is deleted in, so the
+// next occurrence is not spawned into a list that no longer exists.
+func moveSeriesToInbox(todoCollection *mongo.Collection, userCollection *mongo.Collection, userID string, listID primitive.ObjectID) error {
+	_, err := todoCollection.UpdateMany(
+		context.TODO(),
+		bson.M{"recurrence.template.list_id": listID},
+		bson.M{"$set": bson.M{"recurrence.template.list_id": primitive.NilObjectID}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"todos.$[series].recurrence.template.list_id": primitive.NilObjectID}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"series.recurrence.template.list_id": listID}},
+		}),
+	)
+	return err
+}
+
 func GetAllList(listCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
@@ -204,16 +408,30 @@ func GetAllList(listCollection *mongo.Collection, userCollection *mongo.Collecti
 			return
 		}
 
-		if len(user.List) == 0 {
+		shared, err := share.SharedLists(context.TODO(), userCollection, user)
+		if err != nil {
+			log.Println("Error fetching shared lists:", err)
+			http.Error(w, "Failed to fetch List", http.StatusInternalServerError)
+			return
+		}
+
+		if len(user.List) == 0 && len(shared) == 0 {
 			w.WriteHeader(http.StatusOK)
 			w.Header().Set("Content-Type", "application/json")
 			w.Write([]byte(`{"message": "No list found for this user"}`))
 			return
 		}
 
+		sort.SliceStable(user.List, func(i, j int) bool {
+			return user.List[i].Position < user.List[j].Position
+		})
+		for i := range user.List {
+			user.List[i].Role = models.RoleOwner
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(user.List)
+		err = json.NewEncoder(w).Encode(append(user.List, shared...))
 		if err != nil {
 			http.Error(w, "Failed to fetch List", http.StatusInternalServerError)
 			return
@@ -260,27 +478,278 @@ func FindAList(listCollection *mongo.Collection, userCollection *mongo.Collectio
 			return
 		}
 
-		var foundList models.List
-		found := false
-		for _, list := range user.List {
-			if list.ID.Hex() == listID {
-				foundList = list
-				found = true
-				break
-			}
+		id, err := primitive.ObjectIDFromHex(listID)
+		if err != nil || id.IsZero() {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
 		}
 
-		if !found {
+		account, role, err := share.ListAccount(context.TODO(), userCollection, user, id)
+		if errors.Is(err, share.ErrNotFound) {
 			http.Error(w, "List not found", http.StatusNotFound)
 			return
 		}
+		if err != nil {
+			log.Println("Error resolving list:", err)
+			http.Error(w, "Failed to fetch list", http.StatusInternalServerError)
+			return
+		}
+
+		foundList := account.List[findList(account.List, id)]
+		foundList.Role = role
+		if account.ID != user.ID {
+			foundList.OwnerID = account.ID
+			foundList.OwnerName = account.Name
+		}
+
+		todo.DecorateTodos(account.Todo, account, time.Now())
+
+		detail := listDetail{List: foundList, Todos: make([]models.Todo, 0)}
+		for _, member := range todo.ListTodos(account.Todo, foundList.ID) {
+			detail.Todos = append(detail.Todos, *member)
+			detail.Counts.Total++
+			if member.Done {
+				detail.Counts.Done++
+				continue
+			}
+			detail.Counts.Open++
+			if member.Overdue {
+				detail.Counts.Overdue++
+			}
+			if member.DueToday {
+				detail.Counts.DueToday++
+			}
+			if member.Blocked {
+				detail.Counts.Blocked++
+			}
+		}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(foundList)
+		err = json.NewEncoder(w).Encode(detail)
 		if err != nil {
 			http.Error(w, "Failed to encode list", http.StatusInternalServerError)
 			return
 		}
 	}
 }
+
+func UpdateList(listCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findList(user.List, listID)
+		if index == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		list := user.List[index]
+
+		var updateRequest struct {
+			Name  *string `json:"name,omitempty"`
+			Color *string `json:"color,omitempty"`
+			Icon  *string `json:"icon,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		fields := bson.M{}
+		if updateRequest.Name != nil {
+			list.Name = strings.TrimSpace(*updateRequest.Name)
+			if list.Name == "" {
+				http.Error(w, "List name cannot be empty", http.StatusBadRequest)
+				return
+			}
+			fields["name"] = list.Name
+		}
+		if updateRequest.Color != nil {
+			list.Color = strings.TrimSpace(*updateRequest.Color)
+			if list.Color == "" {
+				http.Error(w, "List color cannot be empty", http.StatusBadRequest)
+				return
+			}
+			fields["color"] = list.Color
+		}
+		if updateRequest.Icon != nil {
+			list.Icon = strings.TrimSpace(*updateRequest.Icon)
+			if utf8.RuneCountInString(list.Icon) > maxIconLength {
+				http.Error(w, "List icon is too long", http.StatusBadRequest)
+				return
+			}
+			fields["icon"] = list.Icon
+		}
+
+		if len(fields) == 0 {
+			http.Error(w, "No fields to update", http.StatusBadRequest)
+			return
+		}
+
+		_, err = listCollection.UpdateOne(context.TODO(), bson.M{"_id": listID}, bson.M{"$set": fields})
+		if err != nil {
+			http.Error(w, "Failed to update list", http.StatusInternalServerError)
+			return
+		}
+
+		userFields := bson.M{}
+		for field, value := range fields {
+			userFields["list.$."+field] = value
+		}
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID, "list._id": listID},
+			bson.M{"$set": userFields},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update user list", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "List updated successfully",
+			"list":    list,
+		})
+	}
+}
+
+// ReorderLists takes every list id in the new order and renumbers the
+// positions from zero, which also settles lists created before positions
+// existed.
+func ReorderLists(listCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var request struct {
+			IDs []primitive.ObjectID `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if len(request.IDs) != len(user.List) {
+			http.Error(w, "ids must list every list exactly once", http.StatusBadRequest)
+			return
+		}
+
+		reordered := make([]models.List, 0, len(user.List))
+		seen := make(map[primitive.ObjectID]bool)
+		for _, id := range request.IDs {
+			index := findList(user.List, id)
+			if index == -1 || seen[id] {
+				http.Error(w, "ids must list every list exactly once", http.StatusBadRequest)
+				return
+			}
+			seen[id] = true
+
+			list := user.List[index]
+			list.Position = len(reordered)
+			reordered = append(reordered, list)
+		}
+
+		for _, list := range reordered {
+			_, err := listCollection.UpdateOne(context.TODO(), bson.M{"_id": list.ID}, bson.M{"$set": bson.M{"position": list.Position}})
+			if err != nil {
+				log.Println("Error saving list position:", err)
+				http.Error(w, "Failed to reorder lists", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Only the positions are written, so a column, limit or rename saved
+		// on a list in the meantime is not reverted.
+		positions := bson.M{}
+		filters := make([]interface{}, 0, len(reordered))
+		for i, list := range reordered {
+			name := fmt.Sprintf("l%d", i)
+			positions["list.$["+name+"].position"] = list.Position
+			filters = append(filters, bson.M{name + "._id": list.ID})
+		}
+		if len(reordered) > 0 {
+			_, err = userCollection.UpdateOne(
+				context.TODO(),
+				bson.M{"_id": user.ID},
+				bson.M{"$set": positions},
+				options.Update().SetArrayFilters(options.ArrayFilters{Filters: filters}),
+			)
+			if err != nil {
+				http.Error(w, "Failed to update user list", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Lists reordered successfully",
+			"lists":   reordered,
+		})
+	}
+}
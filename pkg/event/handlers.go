@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/reminder"
 	"github.com/userAdityaa/todo-backend/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -60,6 +63,11 @@ func CreateEvent(eventCollection *mongo.Collection, userCollection *mongo.Collec
 			return
 		}
 
+		if err := reminder.Normalize(event.Reminders, reminder.EventAnchor(event)); err != nil {
+			http.Error(w, "Invalid reminders: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		_, err = eventCollection.InsertOne(context.TODO(), event)
 		if err != nil {
 			log.Println("Error inserting event:", err)
@@ -142,3 +150,23 @@ func GetAllEvent(eventCollection *mongo.Collection, userCollection *mongo.Collec
 		}
 	}
 }
+
+func EventsBetween(events []models.Event, from time.Time, to time.Time) []models.Event {
+	matched := make([]models.Event, 0)
+	for _, event := range events {
+		start, end := event.Start, event.End
+		if start.IsZero() {
+			start = event.Date
+		}
+		if end.Before(start) {
+			end = start
+		}
+		if start.Before(to) && (end.After(from) || start.Equal(from)) {
+			matched = append(matched, event)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].Start.Before(matched[j].Start)
+	})
+	return matched
+}
@@ -0,0 +1,396 @@
+package focus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	KindWork       = "work"
+	KindShortBreak = "short_break"
+	KindLongBreak  = "long_break"
+)
+
+const (
+	StateRunning  = "running"
+	StatePaused   = "paused"
+	StateFinished = "finished"
+)
+
+const maxSessionMinutes = 240
+
+var (
+	ErrSessionActive = errors.New("a focus session is already in progress")
+	ErrNoSession     = errors.New("no focus session in progress")
+	ErrWrongState    = errors.New("focus session is not in the expected state")
+)
+
+var defaultSettings = models.FocusSettings{
+	WorkMinutes:       25,
+	ShortBreakMinutes: 5,
+	LongBreakMinutes:  15,
+	LongBreakEvery:    4,
+}
+
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := config.FocusSessionCollection(db).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: -1}}},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"active": true}).
+				SetName("one_active_session_per_user"),
+		},
+	})
+	return err
+}
+
+// Settings fills in the defaults for anything the user has not configured.
+func Settings(user models.User) models.FocusSettings {
+	settings := user.Focus
+	if settings.WorkMinutes <= 0 {
+		settings.WorkMinutes = defaultSettings.WorkMinutes
+	}
+	if settings.ShortBreakMinutes <= 0 {
+		settings.ShortBreakMinutes = defaultSettings.ShortBreakMinutes
+	}
+	if settings.LongBreakMinutes <= 0 {
+		settings.LongBreakMinutes = defaultSettings.LongBreakMinutes
+	}
+	if settings.LongBreakEvery <= 0 {
+		settings.LongBreakEvery = defaultSettings.LongBreakEvery
+	}
+	return settings
+}
+
+func validKind(kind string) bool {
+	return kind == KindWork || kind == KindShortBreak || kind == KindLongBreak
+}
+
+func plannedMinutes(settings models.FocusSettings, kind string) int {
+	switch kind {
+	case KindShortBreak:
+		return settings.ShortBreakMinutes
+	case KindLongBreak:
+		return settings.LongBreakMinutes
+	}
+	return settings.WorkMinutes
+}
+
+func elapsed(session models.FocusSession, now time.Time) int64 {
+	until := now
+	if session.State == StatePaused && session.PausedAt != nil {
+		until = *session.PausedAt
+	}
+	if session.State == StateFinished && session.EndedAt != nil {
+		until = *session.EndedAt
+	}
+
+	seconds := int64(until.Sub(session.StartedAt).Seconds()) - session.PausedSeconds
+	if seconds < 0 {
+		return 0
+	}
+	if seconds > session.PlannedSeconds {
+		return session.PlannedSeconds
+	}
+	return seconds
+}
+
+// decorate fills in the fields clients need to render a countdown.
+func decorate(session *models.FocusSession, now time.Time) {
+	if session.State == StateFinished {
+		session.Remaining = 0
+		session.EndsAt = nil
+		return
+	}
+
+	session.FocusedSeconds = elapsed(*session, now)
+	session.Remaining = session.PlannedSeconds - session.FocusedSeconds
+	if session.State == StateRunning {
+		endsAt := now.Add(time.Duration(session.Remaining) * time.Second)
+		session.EndsAt = &endsAt
+	} else {
+		session.EndsAt = nil
+	}
+}
+
+func finish(ctx context.Context, collection *mongo.Collection, session *models.FocusSession, endedAt time.Time, completed bool) error {
+	focused := elapsed(*session, endedAt)
+	if session.State == StatePaused && session.PausedAt != nil {
+		endedAt = *session.PausedAt
+	}
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": session.ID, "state": session.State},
+		bson.M{
+			"$set": bson.M{
+				"state":           StateFinished,
+				"ended_at":        endedAt,
+				"focused_seconds": focused,
+				"completed":       completed,
+			},
+			"$unset": bson.M{"active": "", "paused_at": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrWrongState
+	}
+
+	session.State = StateFinished
+	session.EndedAt = &endedAt
+	session.PausedAt = nil
+	session.FocusedSeconds = focused
+	session.Completed = completed
+	session.Active = false
+	return nil
+}
+
+// Current returns the user's session in progress. A running session whose
+// time is up is finished on the spot, so every device sees the same state
+// without anything having to run in the background.
+func Current(ctx context.Context, collection *mongo.Collection, userID string, now time.Time) (*models.FocusSession, error) {
+	var session models.FocusSession
+	err := collection.FindOne(ctx, bson.M{"user_id": userID, "active": true}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.State == StateRunning && elapsed(session, now) >= session.PlannedSeconds {
+		endedAt := session.StartedAt.Add(time.Duration(session.PlannedSeconds+session.PausedSeconds) * time.Second)
+		if err := finish(ctx, collection, &session, endedAt, true); err != nil && !errors.Is(err, ErrWrongState) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	decorate(&session, now)
+	return &session, nil
+}
+
+// NextKind suggests what the user should do after their last session:
+// a break after work, with a long break after every LongBreakEvery completed
+// work sessions of the day, and work after a break.
+func NextKind(ctx context.Context, collection *mongo.Collection, user models.User, loc *time.Location, now time.Time) (string, error) {
+	var last models.FocusSession
+	err := collection.FindOne(
+		ctx,
+		bson.M{"user_id": user.ID, "state": StateFinished},
+		options.FindOne().SetSort(bson.M{"started_at": -1}),
+	).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return KindWork, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if last.Kind != KindWork || !last.Completed {
+		return KindWork, nil
+	}
+
+	local := now.In(loc)
+	startOfDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	completed, err := collection.CountDocuments(ctx, bson.M{
+		"user_id":    user.ID,
+		"kind":       KindWork,
+		"completed":  true,
+		"started_at": bson.M{"$gte": startOfDay},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if completed > 0 && completed%int64(Settings(user).LongBreakEvery) == 0 {
+		return KindLongBreak, nil
+	}
+	return KindShortBreak, nil
+}
+
+func Start(ctx context.Context, collection *mongo.Collection, user models.User, todoID *primitive.ObjectID, kind string, minutes int, now time.Time) (*models.FocusSession, error) {
+	current, err := Current(ctx, collection, user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return current, ErrSessionActive
+	}
+
+	if minutes <= 0 {
+		minutes = plannedMinutes(Settings(user), kind)
+	}
+
+	session := models.FocusSession{
+		ID:             primitive.NewObjectID(),
+		UserID:         user.ID,
+		TodoID:         todoID,
+		Kind:           kind,
+		State:          StateRunning,
+		PlannedSeconds: int64(minutes) * 60,
+		StartedAt:      now,
+		Active:         true,
+	}
+	if _, err := collection.InsertOne(ctx, session); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrSessionActive
+		}
+		return nil, err
+	}
+
+	decorate(&session, now)
+	return &session, nil
+}
+
+func Pause(ctx context.Context, collection *mongo.Collection, userID string, now time.Time) (*models.FocusSession, error) {
+	session, err := Current(ctx, collection, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrNoSession
+	}
+	if session.State != StateRunning {
+		return session, ErrWrongState
+	}
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": session.ID, "state": StateRunning},
+		bson.M{"$set": bson.M{"state": StatePaused, "paused_at": now}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrWrongState
+	}
+
+	session.State = StatePaused
+	session.PausedAt = &now
+	decorate(session, now)
+	return session, nil
+}
+
+func Resume(ctx context.Context, collection *mongo.Collection, userID string, now time.Time) (*models.FocusSession, error) {
+	session, err := Current(ctx, collection, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrNoSession
+	}
+	if session.State != StatePaused || session.PausedAt == nil {
+		return session, ErrWrongState
+	}
+
+	paused := int64(now.Sub(*session.PausedAt).Seconds())
+	if paused < 0 {
+		paused = 0
+	}
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": session.ID, "state": StatePaused},
+		bson.M{
+			"$set":   bson.M{"state": StateRunning},
+			"$inc":   bson.M{"paused_seconds": paused},
+			"$unset": bson.M{"paused_at": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrWrongState
+	}
+
+	session.State = StateRunning
+	session.PausedAt = nil
+	session.PausedSeconds += paused
+	decorate(session, now)
+	return session, nil
+}
+
+// Finish ends the session in progress. A session finished before its time
+// is up counts as interrupted unless completed is set.
+func Finish(ctx context.Context, collection *mongo.Collection, userID string, completed bool, now time.Time) (*models.FocusSession, error) {
+	session, err := Current(ctx, collection, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrNoSession
+	}
+
+	if err := finish(ctx, collection, session, now, completed); err != nil {
+		return nil, err
+	}
+	decorate(session, now)
+	return session, nil
+}
+
+type DayStats struct {
+	Date           string `json:"date"`
+	WorkSessions   int    `json:"work_sessions"`
+	Completed      int    `json:"completed"`
+	Interrupted    int    `json:"interrupted"`
+	FocusedSeconds int64  `json:"focused_seconds"`
+	BreakSeconds   int64  `json:"break_seconds"`
+	LongestStreak  int    `json:"longest_streak"`
+}
+
+// Daily buckets finished sessions by the local day they started on, with an
+// entry for every day in [from, to] so charts do not have to fill gaps.
+// A streak is a run of completed work sessions without an interruption.
+func Daily(sessions []models.FocusSession, from time.Time, to time.Time, loc *time.Location) []DayStats {
+	days := make([]DayStats, 0)
+	index := make(map[string]int)
+	streaks := make(map[int]int)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		index[key] = len(days)
+		days = append(days, DayStats{Date: key})
+	}
+
+	for _, session := range sessions {
+		i, ok := index[session.StartedAt.In(loc).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		day := &days[i]
+
+		if session.Kind != KindWork {
+			day.BreakSeconds += session.FocusedSeconds
+			continue
+		}
+
+		day.WorkSessions++
+		day.FocusedSeconds += session.FocusedSeconds
+		if session.Completed {
+			day.Completed++
+			streaks[i]++
+			if streaks[i] > day.LongestStreak {
+				day.LongestStreak = streaks[i]
+			}
+		} else {
+			day.Interrupted++
+			streaks[i] = 0
+		}
+	}
+	return days
+}
@@ -0,0 +1,583 @@
+package focus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultStatsDays = 7
+	maxStatsDays     = 366
+)
+
+// canSeeTodo reports whether the todo is the user's or sits in a list shared
+// with them. Focus sessions belong to the user, so read access is enough.
+func canSeeTodo(userCollection *mongo.Collection, user models.User, todoID primitive.ObjectID) (bool, error) {
+	_, _, err := share.TodoAccount(context.TODO(), userCollection, user, todoID)
+	if errors.Is(err, share.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func writeSession(w http.ResponseWriter, status int, message string, session *models.FocusSession) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": message,
+		"session": session,
+	})
+}
+
+func sessionError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, ErrNoSession):
+		http.Error(w, "No focus session in progress", http.StatusNotFound)
+	case errors.Is(err, ErrWrongState):
+		http.Error(w, "Cannot "+action+" the focus session in its current state", http.StatusConflict)
+	default:
+		log.Println("Error trying to "+action+" focus session:", err)
+		http.Error(w, "Failed to "+action+" focus session", http.StatusInternalServerError)
+	}
+}
+
+func GetFocus(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		now := time.Now()
+		session, err := Current(context.TODO(), collection, user.ID, now)
+		if err != nil {
+			log.Println("Error fetching focus session:", err)
+			http.Error(w, "Failed to fetch focus session", http.StatusInternalServerError)
+			return
+		}
+
+		next, err := NextKind(context.TODO(), collection, user, utils.LoadLocation(user.TimeZone), now)
+		if err != nil {
+			log.Println("Error fetching focus session:", err)
+			http.Error(w, "Failed to fetch focus session", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"session":   session,
+			"next_kind": next,
+			"settings":  Settings(user),
+		})
+	}
+}
+
+func StartFocus(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var request struct {
+			TodoID  *primitive.ObjectID `json:"todo_id,omitempty"`
+			Kind    string              `json:"kind"`
+			Minutes int                 `json:"minutes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		if request.Kind == "" {
+			request.Kind, err = NextKind(context.TODO(), collection, user, utils.LoadLocation(user.TimeZone), now)
+			if err != nil {
+				sessionError(w, err, "start")
+				return
+			}
+		}
+		if !validKind(request.Kind) {
+			http.Error(w, "Kind must be work, short_break or long_break", http.StatusBadRequest)
+			return
+		}
+
+		if request.Minutes < 0 || request.Minutes > maxSessionMinutes {
+			http.Error(w, fmt.Sprintf("Minutes must be between 1 and %d", maxSessionMinutes), http.StatusBadRequest)
+			return
+		}
+
+		if request.TodoID != nil {
+			if request.Kind != KindWork {
+				http.Error(w, "Only work sessions can be linked to a todo", http.StatusBadRequest)
+				return
+			}
+			visible, err := canSeeTodo(userCollection, user, *request.TodoID)
+			if err != nil {
+				log.Println("Error resolving todo:", err)
+				http.Error(w, "Failed to resolve todo", http.StatusInternalServerError)
+				return
+			}
+			if !visible {
+				http.Error(w, "Todo not found", http.StatusNotFound)
+				return
+			}
+		}
+
+		session, err := Start(context.TODO(), collection, user, request.TodoID, request.Kind, request.Minutes, now)
+		if errors.Is(err, ErrSessionActive) {
+			http.Error(w, "A focus session is already in progress", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			sessionError(w, err, "start")
+			return
+		}
+
+		writeSession(w, http.StatusCreated, "Focus session started successfully", session)
+	}
+}
+
+func PauseFocus(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		session, err := Pause(context.TODO(), collection, user.ID, time.Now())
+		if err != nil {
+			sessionError(w, err, "pause")
+			return
+		}
+
+		writeSession(w, http.StatusOK, "Focus session paused successfully", session)
+	}
+}
+
+func ResumeFocus(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		session, err := Resume(context.TODO(), collection, user.ID, time.Now())
+		if err != nil {
+			sessionError(w, err, "resume")
+			return
+		}
+
+		writeSession(w, http.StatusOK, "Focus session resumed successfully", session)
+	}
+}
+
+func FinishFocus(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var request struct {
+			Completed bool `json:"completed"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		session, err := Finish(context.TODO(), collection, user.ID, request.Completed, time.Now())
+		if err != nil {
+			sessionError(w, err, "finish")
+			return
+		}
+
+		writeSession(w, http.StatusOK, "Focus session finished successfully", session)
+	}
+}
+
+func GetFocusSettings(userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(Settings(user))
+	}
+}
+
+func UpdateFocusSettings(userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var settings models.FocusSettings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		for _, minutes := range []int{settings.WorkMinutes, settings.ShortBreakMinutes, settings.LongBreakMinutes} {
+			if minutes < 0 || minutes > maxSessionMinutes {
+				http.Error(w, fmt.Sprintf("Session lengths must be between 1 and %d minutes", maxSessionMinutes), http.StatusBadRequest)
+				return
+			}
+		}
+		if settings.LongBreakEvery < 0 {
+			http.Error(w, "long_break_every cannot be negative", http.StatusBadRequest)
+			return
+		}
+
+		user.Focus = settings
+		settings = Settings(user)
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$set": bson.M{"focus": settings}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update focus settings", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Focus settings updated successfully",
+			"settings": settings,
+		})
+	}
+}
+
+func GetFocusStats(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		values := r.URL.Query()
+
+		loc := utils.LoadLocation(user.TimeZone)
+		if tz := values.Get("tz"); tz != "" {
+			if loc, err = time.LoadLocation(tz); err != nil {
+				http.Error(w, "Invalid timezone", http.StatusBadRequest)
+				return
+			}
+		}
+
+		now := time.Now()
+		local := now.In(loc)
+		to := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		if value := values.Get("to"); value != "" {
+			if to, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+				http.Error(w, "to must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+		}
+		from := to.AddDate(0, 0, -(defaultStatsDays - 1))
+		if value := values.Get("from"); value != "" {
+			if from, err = time.ParseInLocation("2006-01-02", value, loc); err != nil {
+				http.Error(w, "from must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+		}
+		if from.After(to) || to.Sub(from) > maxStatsDays*24*time.Hour {
+			http.Error(w, fmt.Sprintf("from must be before to and at most %d days apart", maxStatsDays), http.StatusBadRequest)
+			return
+		}
+
+		// Settle a session that ran out while nobody was looking so it counts.
+		if _, err := Current(context.TODO(), collection, user.ID, now); err != nil {
+			log.Println("Error fetching focus session:", err)
+		}
+
+		cursor, err := collection.Find(
+			context.TODO(),
+			bson.M{
+				"user_id":    user.ID,
+				"state":      StateFinished,
+				"started_at": bson.M{"$gte": from, "$lt": to.AddDate(0, 0, 1)},
+			},
+			options.Find().SetSort(bson.M{"started_at": 1}),
+		)
+		if err != nil {
+			http.Error(w, "Failed to fetch focus stats", http.StatusInternalServerError)
+			return
+		}
+
+		var sessions []models.FocusSession
+		if err := cursor.All(context.TODO(), &sessions); err != nil {
+			http.Error(w, "Failed to fetch focus stats", http.StatusInternalServerError)
+			return
+		}
+
+		days := Daily(sessions, from, to, loc)
+
+		totals := DayStats{}
+		for _, day := range days {
+			totals.WorkSessions += day.WorkSessions
+			totals.Completed += day.Completed
+			totals.Interrupted += day.Interrupted
+			totals.FocusedSeconds += day.FocusedSeconds
+			totals.BreakSeconds += day.BreakSeconds
+			if day.LongestStreak > totals.LongestStreak {
+				totals.LongestStreak = day.LongestStreak
+			}
+		}
+
+		byTodo := make(map[string]int64)
+		for _, session := range sessions {
+			if session.Kind == KindWork && session.TodoID != nil {
+				byTodo[session.TodoID.Hex()] += session.FocusedSeconds
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"from":     from.Format("2006-01-02"),
+			"to":       to.Format("2006-01-02"),
+			"timezone": loc.String(),
+			"days":     days,
+			"totals": map[string]interface{}{
+				"work_sessions":   totals.WorkSessions,
+				"completed":       totals.Completed,
+				"interrupted":     totals.Interrupted,
+				"focused_seconds": totals.FocusedSeconds,
+				"break_seconds":   totals.BreakSeconds,
+				"longest_streak":  totals.LongestStreak,
+			},
+			"by_todo": byTodo,
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package habit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxTarget = 100
+
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := config.HabitCheckInCollection(db).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "habit_id", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}}},
+	})
+	return err
+}
+
+func normalize(habit *models.Habit) error {
+	habit.Name = strings.TrimSpace(habit.Name)
+	if habit.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	switch habit.Frequency {
+	case "":
+		habit.Frequency = models.HabitDaily
+	case models.HabitDaily, models.HabitWeekly:
+	default:
+		return fmt.Errorf("frequency must be daily or weekly")
+	}
+
+	if habit.Target == 0 {
+		habit.Target = 1
+	}
+	if habit.Target < 1 || habit.Target > maxTarget {
+		return fmt.Errorf("target must be between 1 and %d", maxTarget)
+	}
+
+	if habit.Frequency == models.HabitWeekly {
+		if len(habit.Days) > 0 {
+			return fmt.Errorf("days only apply to daily habits")
+		}
+		habit.Days = []int{}
+		return nil
+	}
+
+	seen := make(map[int]bool)
+	days := make([]int, 0, len(habit.Days))
+	for _, day := range habit.Days {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("days must be weekdays from 0 (Sunday) to 6 (Saturday)")
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+	habit.Days = days
+	return nil
+}
+
+func findHabit(habits []models.Habit, id primitive.ObjectID) int {
+	for i, habit := range habits {
+		if habit.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkInCounts loads the user's check-ins grouped by habit and date. It
+// also returns the earliest check-in per habit, since back-filled history
+// can predate the habit itself.
+func checkInCounts(ctx context.Context, collection *mongo.Collection, userID string, filter bson.M) (map[primitive.ObjectID]map[string]int, map[primitive.ObjectID]string, error) {
+	query := bson.M{"user_id": userID}
+	for key, value := range filter {
+		query[key] = value
+	}
+
+	cursor, err := collection.Find(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var checkIns []models.HabitCheckIn
+	if err := cursor.All(ctx, &checkIns); err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[primitive.ObjectID]map[string]int)
+	earliest := make(map[primitive.ObjectID]string)
+	for _, checkIn := range checkIns {
+		if counts[checkIn.HabitID] == nil {
+			counts[checkIn.HabitID] = make(map[string]int)
+		}
+		counts[checkIn.HabitID][checkIn.Date] += checkIn.Count
+		if first, ok := earliest[checkIn.HabitID]; !ok || checkIn.Date < first {
+			earliest[checkIn.HabitID] = checkIn.Date
+		}
+	}
+	return counts, earliest, nil
+}
+
+func withStats(habits []models.Habit, counts map[primitive.ObjectID]map[string]int, earliest map[primitive.ObjectID]string, loc *time.Location, now time.Time) {
+	today := civil(now, loc)
+	for i := range habits {
+		first := civil(habits[i].CreatedAt, loc)
+		if date, ok := earliest[habits[i].ID]; ok {
+			if day, err := parseDate(date); err == nil && day.Before(first) {
+				first = day
+			}
+		}
+		if first.After(today) {
+			first = today
+		}
+
+		stats := Stats(habits[i], counts[habits[i].ID], first, today)
+		habits[i].Stats = &stats
+	}
+}
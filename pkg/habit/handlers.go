@@ -0,0 +1,641 @@
+package habit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func habitLocation(r *http.Request, user models.User) (*time.Location, error) {
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		return time.LoadLocation(tz)
+	}
+	return utils.LoadLocation(user.TimeZone), nil
+}
+
+func GetAllHabits(collection *mongo.Collection, checkInCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		loc, err := habitLocation(r, user)
+		if err != nil {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+
+		includeArchived := r.URL.Query().Get("archived") == "true"
+		habits := make([]models.Habit, 0, len(user.Habits))
+		for _, habit := range user.Habits {
+			if includeArchived || !habit.Archived {
+				habits = append(habits, habit)
+			}
+		}
+
+		counts, earliest, err := checkInCounts(context.TODO(), checkInCollection, user.ID, nil)
+		if err != nil {
+			log.Println("Error fetching check-ins:", err)
+			http.Error(w, "Failed to fetch habits", http.StatusInternalServerError)
+			return
+		}
+		withStats(habits, counts, earliest, loc, time.Now())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(habits)
+		if err != nil {
+			log.Println("Error encoding habits:", err)
+			http.Error(w, "Failed to fetch habits", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func CreateHabit(collection *mongo.Collection, checkInCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var habit models.Habit
+		if err := json.NewDecoder(r.Body).Decode(&habit); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := normalize(&habit); err != nil {
+			http.Error(w, "Invalid habit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		habit.ID = primitive.NewObjectID()
+		habit.Archived = false
+		habit.CreatedAt = time.Now()
+		habit.Stats = nil
+
+		if _, err := collection.InsertOne(context.TODO(), habit); err != nil {
+			log.Println("Error inserting habit:", err)
+			http.Error(w, "Failed to create habit", http.StatusInternalServerError)
+			return
+		}
+
+		user.Habits = append(user.Habits, habit)
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$set": bson.M{"habits": user.Habits}},
+		)
+		if err != nil {
+			log.Println("Error updating user:", err)
+			http.Error(w, "Failed to update user with new habit", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Habit created successfully",
+			"habit":   habit,
+		})
+	}
+}
+
+func UpdateHabit(collection *mongo.Collection, checkInCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		habitID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid habit ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findHabit(user.Habits, habitID)
+		if index == -1 {
+			http.Error(w, "Habit not found", http.StatusNotFound)
+			return
+		}
+
+		var updated models.Habit
+		if err := json.NewDecoder(r.Body).Decode(&updated); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := normalize(&updated); err != nil {
+			http.Error(w, "Invalid habit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		updated.ID = habitID
+		updated.CreatedAt = user.Habits[index].CreatedAt
+		updated.Stats = nil
+
+		fields := bson.M{
+			"name":        updated.Name,
+			"description": updated.Description,
+			"color":       updated.Color,
+			"frequency":   updated.Frequency,
+			"target":      updated.Target,
+			"days":        updated.Days,
+			"archived":    updated.Archived,
+		}
+
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": habitID}, bson.M{"$set": fields}); err != nil {
+			log.Println("Error updating habit:", err)
+			http.Error(w, "Failed to update habit", http.StatusInternalServerError)
+			return
+		}
+
+		userFields := bson.M{}
+		for key, value := range fields {
+			userFields["habits.$."+key] = value
+		}
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID, "habits._id": habitID},
+			bson.M{"$set": userFields},
+		)
+		if err != nil {
+			log.Println("Error updating user habits:", err)
+			http.Error(w, "Failed to update user's habits", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Habit updated successfully",
+			"habit":   updated,
+		})
+	}
+}
+
+func DeleteHabit(collection *mongo.Collection, checkInCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		habitID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid habit ID", http.StatusBadRequest)
+			return
+		}
+
+		if findHabit(user.Habits, habitID) == -1 {
+			http.Error(w, "Habit not found", http.StatusNotFound)
+			return
+		}
+
+		if _, err := collection.DeleteOne(context.TODO(), bson.M{"_id": habitID}); err != nil {
+			http.Error(w, "Error deleting habit", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$pull": bson.M{"habits": bson.M{"_id": habitID}}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update user habits", http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := checkInCollection.DeleteMany(context.TODO(), bson.M{"user_id": user.ID, "habit_id": habitID}); err != nil {
+			log.Println("Error deleting habit check-ins:", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Habit deleted successfully"})
+	}
+}
+
+func CheckIn(collection *mongo.Collection, checkInCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		habitID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid habit ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findHabit(user.Habits, habitID)
+		if index == -1 {
+			http.Error(w, "Habit not found", http.StatusNotFound)
+			return
+		}
+
+		var request struct {
+			Date  string `json:"date"`
+			Count int    `json:"count"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		loc, err := habitLocation(r, user)
+		if err != nil {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		today := civil(now, loc)
+		day := today
+		if request.Date != "" {
+			if day, err = parseDate(request.Date); err != nil {
+				http.Error(w, "date must be in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+			if day.After(today) {
+				http.Error(w, "Cannot check in for a future date", http.StatusBadRequest)
+				return
+			}
+			if day.Before(today.AddDate(0, 0, -backfillDays)) {
+				http.Error(w, fmt.Sprintf("Cannot check in more than %d days back", backfillDays), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if request.Count == 0 {
+			request.Count = 1
+		}
+		if request.Count < 1 || request.Count > maxTarget {
+			http.Error(w, "count must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+
+		date := day.Format(dateLayout)
+		var checkIn models.HabitCheckIn
+		err = checkInCollection.FindOneAndUpdate(
+			context.TODO(),
+			bson.M{"habit_id": habitID, "user_id": user.ID, "date": date},
+			bson.M{
+				"$inc":         bson.M{"count": request.Count},
+				"$set":         bson.M{"updated_at": now},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&checkIn)
+		if err != nil {
+			log.Println("Error checking in:", err)
+			http.Error(w, "Failed to check in", http.StatusInternalServerError)
+			return
+		}
+
+		habit := user.Habits[index]
+		counts, earliest, err := checkInCounts(context.TODO(), checkInCollection, user.ID, bson.M{"habit_id": habitID})
+		if err == nil {
+			habits := []models.Habit{habit}
+			withStats(habits, counts, earliest, loc, now)
+			habit = habits[0]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Checked in successfully",
+			"check_in": checkIn,
+			"habit":    habit,
+		})
+	}
+}
+
+func UndoCheckIn(collection *mongo.Collection, checkInCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		habitID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid habit ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findHabit(user.Habits, habitID)
+		if index == -1 {
+			http.Error(w, "Habit not found", http.StatusNotFound)
+			return
+		}
+
+		loc, err := habitLocation(r, user)
+		if err != nil {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		date := civil(now, loc).Format(dateLayout)
+		if value := r.URL.Query().Get("date"); value != "" {
+			day, err := parseDate(value)
+			if err != nil {
+				http.Error(w, "date must be in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+			date = day.Format(dateLayout)
+		}
+
+		var checkIn models.HabitCheckIn
+		err = checkInCollection.FindOneAndUpdate(
+			context.TODO(),
+			bson.M{"habit_id": habitID, "user_id": user.ID, "date": date, "count": bson.M{"$gt": 0}},
+			bson.M{"$inc": bson.M{"count": -1}, "$set": bson.M{"updated_at": now}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&checkIn)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "No check-in on that date", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error undoing check-in:", err)
+			http.Error(w, "Failed to undo check-in", http.StatusInternalServerError)
+			return
+		}
+
+		if checkIn.Count <= 0 {
+			if _, err := checkInCollection.DeleteOne(context.TODO(), bson.M{"_id": checkIn.ID, "count": bson.M{"$lte": 0}}); err != nil {
+				log.Println("Error removing empty check-in:", err)
+			}
+		}
+
+		habit := user.Habits[index]
+		counts, earliest, err := checkInCounts(context.TODO(), checkInCollection, user.ID, bson.M{"habit_id": habitID})
+		if err == nil {
+			habits := []models.Habit{habit}
+			withStats(habits, counts, earliest, loc, now)
+			habit = habits[0]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Check-in removed successfully",
+			"check_in": checkIn,
+			"habit":    habit,
+		})
+	}
+}
+
+func GetHeatmap(collection *mongo.Collection, checkInCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		loc, err := habitLocation(r, user)
+		if err != nil {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+
+		today := civil(time.Now(), loc)
+		filter := bson.M{"date": bson.M{"$gte": today.AddDate(0, 0, -(heatmapDays - 1)).Format(dateLayout)}}
+
+		if value := r.URL.Query().Get("habit_id"); value != "" {
+			habitID, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				http.Error(w, "Invalid habit ID", http.StatusBadRequest)
+				return
+			}
+			if findHabit(user.Habits, habitID) == -1 {
+				http.Error(w, "Habit not found", http.StatusNotFound)
+				return
+			}
+			filter["habit_id"] = habitID
+		}
+
+		counts, _, err := checkInCounts(context.TODO(), checkInCollection, user.ID, filter)
+		if err != nil {
+			log.Println("Error fetching check-ins:", err)
+			http.Error(w, "Failed to fetch heatmap", http.StatusInternalServerError)
+			return
+		}
+
+		total := make(map[string]int)
+		for _, byDate := range counts {
+			for date, count := range byDate {
+				total[date] += count
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"timezone": loc.String(),
+			"days":     Heatmap(total, today),
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package habit
+
+import (
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+)
+
+const dateLayout = "2006-01-02"
+
+const (
+	dailyRateWindow  = 30
+	weeklyRateWindow = 12
+	heatmapDays      = 365
+	// backfillDays bounds how far back a check-in can be logged: a year of
+	// history before the heatmap starts. Streaks are walked day by day from
+	// the oldest check-in, so an unbounded date would make them crawl.
+	backfillDays = 2 * heatmapDays
+)
+
+// civil turns a local calendar day into a UTC midnight so day arithmetic is
+// not thrown off by DST changes in the user's timezone.
+func civil(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func parseDate(value string) (time.Time, error) {
+	return time.Parse(dateLayout, value)
+}
+
+func target(habit models.Habit) int {
+	if habit.Target < 1 {
+		return 1
+	}
+	return habit.Target
+}
+
+func scheduled(habit models.Habit, day time.Time) bool {
+	if habit.Frequency == models.HabitWeekly || len(habit.Days) == 0 {
+		return true
+	}
+	for _, weekday := range habit.Days {
+		if time.Weekday(weekday) == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func periodStart(habit models.Habit, day time.Time) time.Time {
+	if habit.Frequency == models.HabitWeekly {
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+func periodLength(habit models.Habit) int {
+	if habit.Frequency == models.HabitWeekly {
+		return 7
+	}
+	return 1
+}
+
+func periodCount(habit models.Habit, counts map[string]int, start time.Time) int {
+	total := 0
+	for i := 0; i < periodLength(habit); i++ {
+		total += counts[start.AddDate(0, 0, i).Format(dateLayout)]
+	}
+	return total
+}
+
+// Stats works out streaks over the habit's periods (days for daily habits,
+// Monday-based weeks for weekly ones) from first up to today. Days a daily
+// habit is not scheduled on neither extend nor break a streak, and the
+// current period only counts once it is met, so a streak is not lost just
+// because today's check-in has not happened yet. The completion rate covers
+// the last 30 days or 12 weeks.
+func Stats(habit models.Habit, counts map[string]int, first time.Time, today time.Time) models.HabitStats {
+	var stats models.HabitStats
+	goal := target(habit)
+	step := periodLength(habit)
+
+	current := periodStart(habit, today)
+	stats.PeriodCount = periodCount(habit, counts, current)
+	stats.PeriodDone = stats.PeriodCount >= goal
+
+	window := current.AddDate(0, 0, -(dailyRateWindow - 1))
+	if habit.Frequency == models.HabitWeekly {
+		window = current.AddDate(0, 0, -7*(weeklyRateWindow-1))
+	}
+
+	run, met, total := 0, 0, 0
+	for start := periodStart(habit, first); !start.After(current); start = start.AddDate(0, 0, step) {
+		if !scheduled(habit, start) {
+			continue
+		}
+
+		done := periodCount(habit, counts, start) >= goal
+		if done {
+			run++
+			if run > stats.LongestStreak {
+				stats.LongestStreak = run
+			}
+		} else if !start.Equal(current) {
+			run = 0
+		}
+
+		if !start.Before(window) && (done || !start.Equal(current)) {
+			total++
+			if done {
+				met++
+			}
+		}
+	}
+
+	stats.CurrentStreak = run
+	if total > 0 {
+		stats.CompletionRate = float64(met) / float64(total)
+	}
+	return stats
+}
+
+type HeatmapDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// Heatmap returns one entry per day for the year ending today, oldest first.
+func Heatmap(counts map[string]int, today time.Time) []HeatmapDay {
+	days := make([]HeatmapDay, 0, heatmapDays)
+	for day := today.AddDate(0, 0, -(heatmapDays - 1)); !day.After(today); day = day.AddDate(0, 0, 1) {
+		key := day.Format(dateLayout)
+		days = append(days, HeatmapDay{Date: key, Count: counts[key]})
+	}
+	return days
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+)
+
+const (
+	ChannelEmail   = "email"
+	ChannelPush    = "push"
+	ChannelWebhook = "webhook"
+)
+
+var Channels = []string{ChannelEmail, ChannelPush, ChannelWebhook}
+
+var ErrNoRecipient = errors.New("no recipient configured for channel")
+
+type Recipient struct {
+	Name              string
+	Email             string
+	WebhookURL        string
+	PushSubscriptions []models.PushSubscription
+}
+
+type Message struct {
+	Subject  string    `json:"title"`
+	Body     string    `json:"body"`
+	ItemType string    `json:"item_type,omitempty"`
+	ItemID   string    `json:"item_id,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, to Recipient, msg Message) error
+}
+
+// PushError reports subscriptions the push service has expired, alongside
+// any error that should make the delivery be retried.
+type PushError struct {
+	Gone []string
+	Err  error
+}
+
+func (e *PushError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%d push subscriptions expired", len(e.Gone))
+}
+
+func (e *PushError) Unwrap() error {
+	return e.Err
+}
+
+func ValidChannel(channel string) bool {
+	for _, known := range Channels {
+		if channel == known {
+			return true
+		}
+	}
+	return false
+}
+
+func NormalizeChannels(channels []string) ([]string, error) {
+	normalized := make([]string, 0, len(channels))
+	seen := make(map[string]bool)
+	for _, channel := range channels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if !ValidChannel(channel) {
+			return nil, fmt.Errorf("unknown channel %q, expected email, push or webhook", channel)
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			normalized = append(normalized, channel)
+		}
+	}
+	return normalized, nil
+}
+
+func FromConfig() ([]Notifier, error) {
+	var notifiers []Notifier
+
+	if config.SMTPHost != "" {
+		notifiers = append(notifiers, NewSMTP(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom))
+	}
+
+	notifiers = append(notifiers, NewWebhook(config.WebhookSecret))
+
+	if config.VAPIDPrivateKey != "" {
+		push, err := NewWebPush(config.VAPIDPrivateKey, config.VAPIDSubject)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, push)
+	}
+
+	return notifiers, nil
+}
@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the stand-in server saw of one delivery.
+type smtpSession struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// smtpStandIn accepts a single SMTP session on a local port and reports it
+// on the returned channel. It speaks just enough of the protocol for
+// net/smtp: EHLO, AUTH PLAIN, MAIL, RCPT, DATA and QUIT.
+func smtpStandIn(t *testing.T) (string, string, <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var session smtpSession
+		reply("220 localhost ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch verb {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				fields := strings.Fields(line)
+				if len(fields) == 3 {
+					decoded, _ := base64.StdEncoding.DecodeString(fields[2])
+					session.auth = string(decoded)
+				}
+				reply("235 authenticated")
+			case "MAIL":
+				session.from = line
+				reply("250 ok")
+			case "RCPT":
+				session.to = append(session.to, line)
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				session.data = data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				sessions <- session
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	return host, port, sessions
+}
+
+func TestSMTPSend(t *testing.T) {
+	host, port, sessions := smtpStandIn(t)
+	sender := NewSMTP(host, port, "reminders@example.com", "secret", "")
+
+	err := sender.Send(context.Background(), Recipient{Email: "ada@example.com"}, Message{
+		Subject: "Due soon: café order",
+		Body:    "Pay the invoice",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	session := <-sessions
+	if want := "\x00reminders@example.com\x00secret"; session.auth != want {
+		t.Errorf("auth = %q, want %q", session.auth, want)
+	}
+	if !strings.Contains(session.from, "<reminders@example.com>") {
+		t.Errorf("MAIL = %q, want the username as sender", session.from)
+	}
+	if len(session.to) != 1 || !strings.Contains(session.to[0], "<ada@example.com>") {
+		t.Errorf("RCPT = %q, want ada@example.com", session.to)
+	}
+	for _, want := range []string{
+		"From: reminders@example.com\r\n",
+		"To: ada@example.com\r\n",
+		"Subject: =?utf-8?q?Due_soon:_caf=C3=A9_order?=\r\n",
+		"Content-Type: text/plain; charset=UTF-8\r\n",
+		"\r\n\r\nPay the invoice\r\n",
+	} {
+		if !strings.Contains(session.data, want) {
+			t.Errorf("message is missing %q:\n%s", want, session.data)
+		}
+	}
+}
+
+func TestSMTPSendWithoutAuth(t *testing.T) {
+	host, port, sessions := smtpStandIn(t)
+	sender := NewSMTP(host, port, "", "", "noreply@example.com")
+
+	if err := sender.Send(context.Background(), Recipient{Email: "ada@example.com"}, Message{Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	session := <-sessions
+	if session.auth != "" {
+		t.Errorf("authenticated as %q without a username", session.auth)
+	}
+	if !strings.Contains(session.from, "<noreply@example.com>") {
+		t.Errorf("MAIL = %q, want noreply@example.com", session.from)
+	}
+}
+
+func TestSMTPSendNoRecipient(t *testing.T) {
+	sender := NewSMTP("127.0.0.1", "1", "", "", "noreply@example.com")
+	if err := sender.Send(context.Background(), Recipient{}, Message{Subject: "Hi"}); !errors.Is(err, ErrNoRecipient) {
+		t.Errorf("Send = %v, want ErrNoRecipient", err)
+	}
+}
+
+func TestSMTPSendCanceled(t *testing.T) {
+	// A listener that never answers leaves the client waiting for the
+	// greeting, so only the context can end the send.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = NewSMTP(host, port, "", "", "noreply@example.com").Send(ctx, Recipient{Email: "ada@example.com"}, Message{Subject: "Hi"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,234 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/userAdityaa/todo-backend/models"
+)
+
+const (
+	pushRecordSize = 4096
+	pushTTL        = 24 * time.Hour
+)
+
+var errSubscriptionGone = errors.New("push subscription expired")
+
+type WebPush struct {
+	Subject   string
+	PublicKey string
+	Client    *http.Client
+
+	privateKey *ecdsa.PrivateKey
+}
+
+func decodeBase64URL(value string) ([]byte, error) {
+	value = strings.NewReplacer("+", "-", "/", "_").Replace(strings.TrimRight(value, "="))
+	return base64.RawURLEncoding.DecodeString(value)
+}
+
+func NewWebPush(privateKey string, subject string) (*WebPush, error) {
+	raw, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %v", err)
+	}
+
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %v", err)
+	}
+
+	public := key.PublicKey().Bytes()
+	if subject == "" {
+		subject = "https://minimal-planner.vercel.app"
+	}
+
+	return &WebPush{
+		Subject:   subject,
+		PublicKey: base64.RawURLEncoding.EncodeToString(public),
+		Client:    &http.Client{Timeout: 10 * time.Second},
+		privateKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(public[1:33]),
+				Y:     new(big.Int).SetBytes(public[33:]),
+			},
+			D: new(big.Int).SetBytes(raw),
+		},
+	}, nil
+}
+
+func (p *WebPush) Channel() string {
+	return ChannelPush
+}
+
+func (p *WebPush) Send(ctx context.Context, to Recipient, msg Message) error {
+	if len(to.PushSubscriptions) == 0 {
+		return ErrNoRecipient
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	var pushErr PushError
+	for _, subscription := range to.PushSubscriptions {
+		err := p.deliver(ctx, subscription, payload)
+		switch {
+		case errors.Is(err, errSubscriptionGone):
+			pushErr.Gone = append(pushErr.Gone, subscription.Endpoint)
+		case err != nil:
+			pushErr.Err = err
+		}
+	}
+
+	if pushErr.Err != nil || len(pushErr.Gone) > 0 {
+		return &pushErr
+	}
+	return nil
+}
+
+func (p *WebPush) deliver(ctx context.Context, subscription models.PushSubscription, payload []byte) error {
+	body, err := encryptPushPayload(subscription, payload)
+	if err != nil {
+		return err
+	}
+
+	endpoint, err := url.Parse(subscription.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid push endpoint: %v", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": p.Subject,
+	})
+	signed, err := token.SignedString(p.privateKey)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(int(pushTTL.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", signed, p.PublicKey))
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errSubscriptionGone
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("push service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func hkdfExtract(salt []byte, ikm []byte) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+// hkdfExpand only produces a single HMAC block, which is all the web push
+// key schedule ever asks for.
+func hkdfExpand(prk []byte, info []byte, length int) []byte {
+	mac := hmac.New(sha256.New, prk)
+	mac.Write(info)
+	mac.Write([]byte{1})
+	return mac.Sum(nil)[:length]
+}
+
+// encryptPushPayload implements the aes128gcm content coding from RFC 8291.
+func encryptPushPayload(subscription models.PushSubscription, payload []byte) ([]byte, error) {
+	if len(payload) > pushRecordSize-17 {
+		return nil, fmt.Errorf("push payload too large")
+	}
+
+	userPublicBytes, err := decodeBase64URL(subscription.Keys.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %v", err)
+	}
+	authSecret, err := decodeBase64URL(subscription.Keys.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %v", err)
+	}
+
+	userPublic, err := ecdh.P256().NewPublicKey(userPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %v", err)
+	}
+
+	serverPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serverPublicBytes := serverPrivate.PublicKey().Bytes()
+
+	sharedSecret, err := serverPrivate.ECDH(userPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), userPublicBytes...)
+	keyInfo = append(keyInfo, serverPublicBytes...)
+	ikm := hkdfExpand(hkdfExtract(authSecret, sharedSecret), keyInfo, 32)
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	prk := hkdfExtract(salt, ikm)
+	contentKey := hkdfExpand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdfExpand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	record := append(append([]byte{}, payload...), 2)
+
+	header := make([]byte, 0, 21+len(serverPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(serverPublicBytes)))
+	header = append(header, serverPublicBytes...)
+
+	return gcm.Seal(header, nonce, record, nil), nil
+}
@@ -0,0 +1,388 @@
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/notify"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func CronTick(scheduler *Scheduler, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !utils.CheckCronSecret(w, r, secret) {
+			return
+		}
+
+		result, err := scheduler.Tick(r.Context(), time.Now())
+		if err != nil {
+			log.Println("Reminder tick failed:", err)
+			http.Error(w, "Failed to process reminders", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+func GetNotificationSettings(userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		if user.Notifications.DefaultChannels == nil {
+			user.Notifications.DefaultChannels = []string{}
+		}
+		if user.Notifications.PushSubscriptions == nil {
+			user.Notifications.PushSubscriptions = []models.PushSubscription{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(user.Notifications)
+	}
+}
+
+func UpdateNotificationSettings(userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var settingsRequest struct {
+			DefaultChannels []string `json:"default_channels"`
+			WebhookURL      string   `json:"webhook_url"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&settingsRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		channels, err := notify.NormalizeChannels(settingsRequest.DefaultChannels)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if settingsRequest.WebhookURL != "" {
+			if err := notify.ValidateWebhookURL(r.Context(), settingsRequest.WebhookURL); err != nil {
+				http.Error(w, "Invalid webhook URL: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$set": bson.M{
+				"notifications.default_channels": channels,
+				"notifications.webhook_url":      settingsRequest.WebhookURL,
+			}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update notification settings", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":          "Notification settings updated successfully",
+			"default_channels": channels,
+			"webhook_url":      settingsRequest.WebhookURL,
+		})
+	}
+}
+
+func AddPushSubscription(userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var subscription models.PushSubscription
+		if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		endpoint, err := url.Parse(subscription.Endpoint)
+		if err != nil || endpoint.Scheme != "https" || subscription.Keys.P256dh == "" || subscription.Keys.Auth == "" {
+			http.Error(w, "Subscription needs an https endpoint and p256dh and auth keys", http.StatusBadRequest)
+			return
+		}
+
+		subscriptions := make([]models.PushSubscription, 0, len(user.Notifications.PushSubscriptions)+1)
+		for _, existing := range user.Notifications.PushSubscriptions {
+			if existing.Endpoint != subscription.Endpoint {
+				subscriptions = append(subscriptions, existing)
+			}
+		}
+		subscriptions = append(subscriptions, subscription)
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$set": bson.M{"notifications.push_subscriptions": subscriptions}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to save push subscription", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Push subscription saved successfully",
+			"endpoint": subscription.Endpoint,
+		})
+	}
+}
+
+func RemovePushSubscription(userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var removeRequest struct {
+			Endpoint string `json:"endpoint"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&removeRequest); err != nil || removeRequest.Endpoint == "" {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		result, err := userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$pull": bson.M{"notifications.push_subscriptions": bson.M{"endpoint": removeRequest.Endpoint}}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to remove push subscription", http.StatusInternalServerError)
+			return
+		}
+
+		if result.ModifiedCount == 0 {
+			http.Error(w, "Push subscription not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Push subscription removed successfully",
+		})
+	}
+}
+
+func GetVAPIDPublicKey(scheduler *Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		push, ok := scheduler.Notifier(notify.ChannelPush).(*notify.WebPush)
+		if !ok {
+			http.Error(w, "Web push is not configured", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"public_key": push.PublicKey,
+		})
+	}
+}
+
+func SendTestNotification(scheduler *Scheduler, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var testRequest struct {
+			Channel string `json:"channel"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&testRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		notifier := scheduler.Notifier(testRequest.Channel)
+		if notifier == nil {
+			http.Error(w, "Channel is not configured", http.StatusBadRequest)
+			return
+		}
+
+		err = notifier.Send(r.Context(), recipientFor(user), notify.Message{
+			Subject: "Test notification",
+			Body:    "Notifications from your planner are working.",
+			At:      time.Now(),
+		})
+
+		var pushErr *notify.PushError
+		if errors.As(err, &pushErr) && len(pushErr.Gone) > 0 {
+			scheduler.pruneSubscriptions(r.Context(), user.ID, pushErr.Gone)
+		}
+
+		if errors.Is(err, notify.ErrNoRecipient) {
+			http.Error(w, "No recipient configured for this channel", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Println("Test notification failed:", err)
+			http.Error(w, "Failed to send test notification: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Test notification sent",
+			"channel": testRequest.Channel,
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package reminder
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/notify"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxRemindersPerItem = 10
+
+const (
+	ItemTodo  = "todo"
+	ItemEvent = "event"
+)
+
+func Normalize(reminders []models.Reminder, anchor *time.Time) error {
+	if len(reminders) > maxRemindersPerItem {
+		return fmt.Errorf("at most %d reminders are allowed", maxRemindersPerItem)
+	}
+
+	for i := range reminders {
+		if reminders[i].ID.IsZero() {
+			reminders[i].ID = primitive.NewObjectID()
+		}
+
+		if reminders[i].At == nil {
+			if anchor == nil || anchor.IsZero() {
+				return fmt.Errorf("reminders relative to the due date need a due date")
+			}
+			if reminders[i].OffsetMinutes < 0 {
+				return fmt.Errorf("offset_minutes cannot be negative")
+			}
+		} else {
+			reminders[i].OffsetMinutes = 0
+		}
+
+		channels, err := notify.NormalizeChannels(reminders[i].Channels)
+		if err != nil {
+			return err
+		}
+		reminders[i].Channels = channels
+	}
+	return nil
+}
+
+func FireTime(reminder models.Reminder, anchor *time.Time) (time.Time, bool) {
+	if reminder.At != nil {
+		return *reminder.At, true
+	}
+	if anchor == nil || anchor.IsZero() {
+		return time.Time{}, false
+	}
+	return anchor.Add(-time.Duration(reminder.OffsetMinutes) * time.Minute), true
+}
+
+func EventAnchor(event models.Event) *time.Time {
+	if !event.Start.IsZero() {
+		return &event.Start
+	}
+	if !event.Date.IsZero() {
+		return &event.Date
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/notify"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	planHorizon   = time.Hour
+	missedGrace   = 6 * time.Hour
+	leaseDuration = 2 * time.Minute
+	maxAttempts   = 5
+	batchSize     = 100
+	jobRetention  = 30 * 24 * time.Hour
+)
+
+const (
+	statusPending   = "pending"
+	statusSent      = "sent"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+	statusCancelled = "cancelled"
+)
+
+type job struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	UserID     string             `bson:"user_id"`
+	ItemType   string             `bson:"item_type"`
+	ItemID     primitive.ObjectID `bson:"item_id"`
+	ReminderID primitive.ObjectID `bson:"reminder_id"`
+	FireAt     time.Time          `bson:"fire_at"`
+	Status     string             `bson:"status"`
+	Attempts   int                `bson:"attempts"`
+	Delivered  []string           `bson:"delivered"`
+	LeaseUntil time.Time          `bson:"lease_until"`
+	LastError  string             `bson:"last_error,omitempty"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty"`
+}
+
+type TickResult struct {
+	Planned   int `json:"planned"`
+	Sent      int `json:"sent"`
+	Skipped   int `json:"skipped"`
+	Retrying  int `json:"retrying"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+}
+
+type Scheduler struct {
+	reminders *mongo.Collection
+	users     *mongo.Collection
+	notifiers map[string]notify.Notifier
+}
+
+func NewScheduler(reminderCollection *mongo.Collection, userCollection *mongo.Collection, notifiers []notify.Notifier) *Scheduler {
+	scheduler := &Scheduler{
+		reminders: reminderCollection,
+		users:     userCollection,
+		notifiers: make(map[string]notify.Notifier),
+	}
+	for _, notifier := range notifiers {
+		scheduler.notifiers[notifier.Channel()] = notifier
+	}
+	return scheduler
+}
+
+func (s *Scheduler) Notifier(channel string) notify.Notifier {
+	return s.notifiers[channel]
+}
+
+func (s *Scheduler) EnsureIndexes(ctx context.Context) error {
+	_, err := s.reminders.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "item_id", Value: 1}, {Key: "reminder_id", Value: 1}, {Key: "fire_at", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "fire_at", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "finished_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(jobRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Tick(ctx, time.Now()); err != nil {
+			log.Println("Reminder tick failed:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) (TickResult, error) {
+	var result TickResult
+
+	planned, err := s.plan(ctx, now)
+	if err != nil {
+		return result, err
+	}
+	result.Planned = planned
+
+	for i := 0; i < batchSize; i++ {
+		var claimed job
+		err := s.reminders.FindOneAndUpdate(
+			ctx,
+			bson.M{"status": statusPending, "fire_at": bson.M{"$lte": now}, "lease_until": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"lease_until": now.Add(leaseDuration)}, "$inc": bson.M{"attempts": 1}},
+			options.FindOneAndUpdate().SetSort(bson.M{"fire_at": 1}).SetReturnDocument(options.After),
+		).Decode(&claimed)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		status, err := s.deliver(ctx, claimed, now)
+		if err != nil {
+			return result, err
+		}
+
+		switch status {
+		case statusSent:
+			result.Sent++
+		case statusSkipped:
+			result.Skipped++
+		case statusPending:
+			result.Retrying++
+		case statusFailed:
+			result.Failed++
+		case statusCancelled:
+			result.Cancelled++
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Scheduler) plan(ctx context.Context, now time.Time) (int, error) {
+	cursor, err := s.users.Find(ctx, bson.M{"$or": []bson.M{
+		{"todos.reminders.0": bson.M{"$exists": true}},
+		{"event.reminders.0": bson.M{"$exists": true}},
+	}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	planned := 0
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return planned, err
+		}
+
+		for _, todo := range user.Todo {
+			if todo.Done {
+				continue
+			}
+			for _, reminder := range todo.Reminders {
+				count, err := s.schedule(ctx, user.ID, ItemTodo, todo.ID, reminder, todo.DueDate, now)
+				if err != nil {
+					return planned, err
+				}
+				planned += count
+			}
+		}
+
+		for _, event := range user.Event {
+			for _, reminder := range event.Reminders {
+				count, err := s.schedule(ctx, user.ID, ItemEvent, event.ID, reminder, EventAnchor(event), now)
+				if err != nil {
+					return planned, err
+				}
+				planned += count
+			}
+		}
+	}
+
+	return planned, cursor.Err()
+}
+
+func (s *Scheduler) schedule(ctx context.Context, userID string, itemType string, itemID primitive.ObjectID, reminder models.Reminder, anchor *time.Time, now time.Time) (int, error) {
+	fireAt, ok := FireTime(reminder, anchor)
+	if !ok || fireAt.Before(now.Add(-missedGrace)) || fireAt.After(now.Add(planHorizon)) {
+		return 0, nil
+	}
+
+	result, err := s.reminders.UpdateOne(
+		ctx,
+		bson.M{"item_id": itemID, "reminder_id": reminder.ID, "fire_at": fireAt},
+		bson.M{"$setOnInsert": bson.M{
+			"user_id":     userID,
+			"item_type":   itemType,
+			"status":      statusPending,
+			"attempts":    0,
+			"delivered":   []string{},
+			"lease_until": time.Time{},
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.UpsertedCount), nil
+}
+
+func (s *Scheduler) resolve(user models.User, claimed job) (models.Reminder, string, *time.Time, bool, bool) {
+	var reminders []models.Reminder
+	var title string
+	var anchor *time.Time
+	allDay := false
+
+	switch claimed.ItemType {
+	case ItemTodo:
+		for _, todo := range user.Todo {
+			if todo.ID == claimed.ItemID && !todo.Done {
+				reminders, title, anchor, allDay = todo.Reminders, todo.Name, todo.DueDate, todo.AllDay
+			}
+		}
+	case ItemEvent:
+		for _, event := range user.Event {
+			if event.ID == claimed.ItemID {
+				reminders, title, anchor = event.Reminders, event.Title, EventAnchor(event)
+			}
+		}
+	}
+
+	for _, reminder := range reminders {
+		if reminder.ID != claimed.ReminderID {
+			continue
+		}
+		if fireAt, ok := FireTime(reminder, anchor); ok && fireAt.Equal(claimed.FireAt) {
+			return reminder, title, anchor, allDay, true
+		}
+	}
+	return models.Reminder{}, "", nil, false, false
+}
+
+func reminderMessage(user models.User, claimed job, title string, anchor *time.Time, allDay bool) notify.Message {
+	msg := notify.Message{
+		Subject:  "Reminder: " + title,
+		Body:     title,
+		ItemType: claimed.ItemType,
+		ItemID:   claimed.ItemID.Hex(),
+		At:       claimed.FireAt,
+	}
+
+	if anchor != nil {
+		when := anchor.In(utils.LoadLocation(user.TimeZone))
+		formatted := when.Format("Mon, Jan 2 at 3:04 PM MST")
+		if allDay {
+			formatted = when.Format("Mon, Jan 2")
+		}
+
+		if claimed.ItemType == ItemEvent {
+			msg.Body = fmt.Sprintf("%s starts %s", title, formatted)
+		} else {
+			msg.Body = fmt.Sprintf("%s is due %s", title, formatted)
+		}
+	}
+	return msg
+}
+
+func recipientFor(user models.User) notify.Recipient {
+	return notify.Recipient{
+		Name:              user.Name,
+		Email:             user.Email,
+		WebhookURL:        user.Notifications.WebhookURL,
+		PushSubscriptions: user.Notifications.PushSubscriptions,
+	}
+}
+
+func (s *Scheduler) finish(ctx context.Context, claimed job, status string, lastError string, now time.Time) (string, error) {
+	fields := bson.M{"status": status, "delivered": claimed.Delivered, "last_error": lastError}
+	if status == statusPending {
+		backoff := time.Duration(claimed.Attempts*claimed.Attempts) * time.Minute
+		fields["lease_until"] = now.Add(backoff)
+	} else {
+		fields["finished_at"] = now
+	}
+
+	_, err := s.reminders.UpdateOne(ctx, bson.M{"_id": claimed.ID}, bson.M{"$set": fields})
+	return status, err
+}
+
+func (s *Scheduler) deliver(ctx context.Context, claimed job, now time.Time) (string, error) {
+	var user models.User
+	err := s.users.FindOne(ctx, bson.M{"_id": claimed.UserID}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s.finish(ctx, claimed, statusCancelled, "user not found", now)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	reminder, title, anchor, allDay, ok := s.resolve(user, claimed)
+	if !ok {
+		return s.finish(ctx, claimed, statusCancelled, "reminder no longer applies", now)
+	}
+
+	channels := reminder.Channels
+	if len(channels) == 0 {
+		channels = user.Notifications.DefaultChannels
+	}
+	if len(channels) == 0 {
+		channels = []string{notify.ChannelEmail}
+	}
+
+	delivered := make(map[string]bool)
+	for _, channel := range claimed.Delivered {
+		delivered[channel] = true
+	}
+
+	msg := reminderMessage(user, claimed, title, anchor, allDay)
+	recipient := recipientFor(user)
+
+	var failures []string
+	sent := false
+	for _, channel := range channels {
+		if delivered[channel] {
+			sent = true
+			continue
+		}
+
+		notifier := s.notifiers[channel]
+		if notifier == nil {
+			log.Printf("Reminder %s: channel %s is not configured", claimed.ID.Hex(), channel)
+			continue
+		}
+
+		err := notifier.Send(ctx, recipient, msg)
+
+		var pushErr *notify.PushError
+		if errors.As(err, &pushErr) {
+			if len(pushErr.Gone) > 0 {
+				s.pruneSubscriptions(ctx, user.ID, pushErr.Gone)
+			}
+			if pushErr.Err == nil && len(pushErr.Gone) < len(recipient.PushSubscriptions) {
+				err = nil
+			} else if pushErr.Err == nil {
+				err = notify.ErrNoRecipient
+			}
+		}
+
+		switch {
+		case err == nil:
+			sent = true
+			claimed.Delivered = append(claimed.Delivered, channel)
+		case errors.Is(err, notify.ErrNoRecipient):
+			log.Printf("Reminder %s: no %s recipient for user %s", claimed.ID.Hex(), channel, user.ID)
+		default:
+			failures = append(failures, channel+": "+err.Error())
+		}
+	}
+
+	switch {
+	case len(failures) > 0 && claimed.Attempts < maxAttempts:
+		return s.finish(ctx, claimed, statusPending, strings.Join(failures, "; "), now)
+	case len(failures) > 0:
+		return s.finish(ctx, claimed, statusFailed, strings.Join(failures, "; "), now)
+	case sent:
+		return s.finish(ctx, claimed, statusSent, "", now)
+	default:
+		return s.finish(ctx, claimed, statusSkipped, "no configured channel could reach the user", now)
+	}
+}
+
+func (s *Scheduler) pruneSubscriptions(ctx context.Context, userID string, endpoints []string) {
+	_, err := s.users.UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$pull": bson.M{"notifications.push_subscriptions": bson.M{"endpoint": bson.M{"$in": endpoints}}}},
+	)
+	if err != nil {
+		log.Println("Failed to prune expired push subscriptions:", err)
+	}
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+func Search(userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		values := r.URL.Query()
+		query := values.Get("q")
+		if len(Terms(query)) == 0 {
+			http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+			return
+		}
+
+		types := make(map[string]bool)
+		for _, value := range values["type"] {
+			for _, kind := range strings.Split(value, ",") {
+				kind = strings.ToLower(strings.TrimSpace(kind))
+				valid := false
+				for _, known := range Types {
+					valid = valid || kind == known
+				}
+				if !valid {
+					http.Error(w, "Type must be one of todo, sticky, list, event", http.StatusBadRequest)
+					return
+				}
+				types[kind] = true
+			}
+		}
+		if len(types) == 0 {
+			for _, kind := range Types {
+				types[kind] = true
+			}
+		}
+
+		page := 1
+		if value := values.Get("page"); value != "" {
+			page, err = strconv.Atoi(value)
+			if err != nil || page < 1 {
+				http.Error(w, "Page must be a positive integer", http.StatusBadRequest)
+				return
+			}
+		}
+
+		limit := defaultLimit
+		if value := values.Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxLimit {
+				http.Error(w, "Limit must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+		}
+
+		results := Run(user, query, types)
+
+		counts := make(map[string]int, len(Types))
+		for _, kind := range Types {
+			if types[kind] {
+				counts[kind] = 0
+			}
+		}
+		for _, result := range results {
+			counts[result.Type]++
+		}
+
+		// Pages past the end are empty. Comparing before multiplying keeps a
+		// huge ?page= from overflowing start.
+		start := len(results)
+		if page-1 <= len(results)/limit {
+			start = min((page-1)*limit, len(results))
+		}
+		end := start + limit
+		if end > len(results) {
+			end = len(results)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(map[string]interface{}{
+			"query":    query,
+			"total":    len(results),
+			"page":     page,
+			"limit":    limit,
+			"has_more": end < len(results),
+			"counts":   counts,
+			"results":  results[start:end],
+		})
+		if err != nil {
+			log.Println("Error encoding search results:", err)
+			http.Error(w, "Failed to search", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	snippetLength  = 160
+	snippetContext = 40
+)
+
+var Types = []string{"todo", "sticky", "list", "event"}
+
+type field struct {
+	name   string
+	text   string
+	weight float64
+}
+
+type document struct {
+	kind   string
+	id     primitive.ObjectID
+	title  string
+	fields []field
+	item   interface{}
+}
+
+type span struct {
+	start int
+	end   int
+}
+
+type Result struct {
+	Type    string      `json:"type"`
+	ID      string      `json:"id"`
+	Title   string      `json:"title"`
+	Field   string      `json:"field"`
+	Snippet string      `json:"snippet"`
+	Score   float64     `json:"score"`
+	Item    interface{} `json:"item"`
+
+	created primitive.ObjectID
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func Terms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, term := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool { return !isWordRune(r) }) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func lowerRunes(text string) []rune {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+func hasRunePrefix(runes []rune, prefix []rune) bool {
+	if len(prefix) > len(runes) {
+		return false
+	}
+	for i := range prefix {
+		if runes[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTerms finds every word in text that starts with one of the terms and
+// returns the matched prefixes as rune spans, grouped by term.
+func matchTerms(text string, terms []string) map[string][]span {
+	runes := lowerRunes(text)
+	matches := make(map[string][]span)
+
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+
+		for _, term := range terms {
+			termRunes := []rune(term)
+			if hasRunePrefix(runes[i:end], termRunes) {
+				matches[term] = append(matches[term], span{start: i, end: i + len(termRunes)})
+			}
+		}
+		i = end
+	}
+	return matches
+}
+
+func score(doc document, terms []string, phrase string) (float64, field, []span, bool) {
+	matchedTerms := make(map[string]bool)
+	total := 0.0
+	var best field
+	var bestSpans []span
+	bestScore := 0.0
+
+	for _, f := range doc.fields {
+		if f.text == "" {
+			continue
+		}
+
+		matches := matchTerms(f.text, terms)
+		fieldScore := 0.0
+		var spans []span
+		for term, termSpans := range matches {
+			matchedTerms[term] = true
+			fieldScore += f.weight * float64(len(termSpans))
+			spans = append(spans, termSpans...)
+		}
+		if fieldScore == 0 {
+			continue
+		}
+
+		lower := strings.ToLower(f.text)
+		if len(terms) > 1 && strings.Contains(lower, phrase) {
+			fieldScore += 2 * f.weight
+		}
+		if strings.TrimSpace(lower) == phrase {
+			fieldScore += 3 * f.weight
+		}
+
+		total += fieldScore
+		if fieldScore > bestScore {
+			best, bestSpans, bestScore = f, spans, fieldScore
+		}
+	}
+
+	return total, best, bestSpans, len(matchedTerms) == len(terms)
+}
+
+func snippet(text string, spans []span) string {
+	runes := []rune(text)
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	start := 0
+	if len(spans) > 0 && spans[0].start > snippetContext {
+		start = spans[0].start - snippetContext
+		for start < spans[0].start && isWordRune(runes[start-1]) {
+			start++
+		}
+	}
+	end := start + snippetLength
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	position := start
+	for _, s := range spans {
+		if s.start < position || s.end > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[position:s.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[s.start:s.end])))
+		b.WriteString("</mark>")
+		position = s.end
+	}
+	b.WriteString(html.EscapeString(string(runes[position:end])))
+
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func documents(user models.User, types map[string]bool) []document {
+	var docs []document
+
+	if types["todo"] {
+		for _, todo := range user.Todo {
+			todo := todo
+			fields := []field{
+				{name: "name", text: todo.Name, weight: 5},
+				{name: "description", text: todo.Description, weight: 2},
+			}
+			for _, subtask := range todo.Subtask {
+				fields = append(fields, field{name: "sub_task", text: subtask.Title, weight: 2})
+			}
+			docs = append(docs, document{kind: "todo", id: todo.ID, title: todo.Name, fields: fields, item: todo})
+		}
+	}
+
+	if types["sticky"] {
+		for _, sticky := range user.Stick {
+			sticky := sticky
+			docs = append(docs, document{kind: "sticky", id: sticky.ID, title: sticky.Topic, item: sticky, fields: []field{
+				{name: "topic", text: sticky.Topic, weight: 5},
+				{name: "content", text: sticky.Content, weight: 2},
+			}})
+		}
+	}
+
+	if types["list"] {
+		for _, list := range user.List {
+			list := list
+			docs = append(docs, document{kind: "list", id: list.ID, title: list.Name, item: list, fields: []field{
+				{name: "name", text: list.Name, weight: 5},
+			}})
+		}
+	}
+
+	if types["event"] {
+		for _, event := range user.Event {
+			event := event
+			docs = append(docs, document{kind: "event", id: event.ID, title: event.Title, item: event, fields: []field{
+				{name: "title", text: event.Title, weight: 5},
+			}})
+		}
+	}
+
+	return docs
+}
+
+func Run(user models.User, query string, types map[string]bool) []Result {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return []Result{}
+	}
+	phrase := strings.Join(terms, " ")
+
+	results := make([]Result, 0)
+	for _, doc := range documents(user, types) {
+		total, best, spans, all := score(doc, terms, phrase)
+		if !all {
+			continue
+		}
+
+		results = append(results, Result{
+			Type:    doc.kind,
+			ID:      doc.id.Hex(),
+			Title:   doc.title,
+			Field:   best.name,
+			Snippet: snippet(best.text, spans),
+			Score:   total,
+			Item:    doc.item,
+			created: doc.id,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].created.Timestamp().After(results[j].created.Timestamp())
+	})
+	return results
+}
@@ -0,0 +1,717 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/notify"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultInviteHours = 7 * 24
+	maxInviteHours     = 30 * 24
+)
+
+func GetMembers(memberCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil || listID.IsZero() {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		owner, role, err := ListAccount(context.TODO(), userCollection, user, listID)
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error resolving list:", err)
+			http.Error(w, "Failed to fetch members", http.StatusInternalServerError)
+			return
+		}
+
+		members, err := Members(context.TODO(), memberCollection.Database(), listID)
+		if err != nil {
+			log.Println("Error fetching members:", err)
+			http.Error(w, "Failed to fetch members", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"owner":   models.Mention{UserID: owner.ID, Name: owner.Name, Email: owner.Email},
+			"members": members,
+			"role":    role,
+		})
+	}
+}
+
+func UpdateMember(memberCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		if findList(user.List, listID) == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+
+		var updateRequest struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&updateRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if !ValidRole(updateRequest.Role) {
+			http.Error(w, "Role must be editor or viewer", http.StatusBadRequest)
+			return
+		}
+
+		var member models.ListMember
+		err = memberCollection.FindOneAndUpdate(
+			context.TODO(),
+			bson.M{"list_id": listID, "user_id": chi.URLParam(r, "userId")},
+			bson.M{"$set": bson.M{"role": updateRequest.Role}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&member)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Member not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error updating member:", err)
+			http.Error(w, "Failed to update member", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Member updated successfully",
+			"member":  member,
+		})
+	}
+}
+
+// RemoveMember lets the owner remove anyone from the list and a member
+// remove themselves, which is how a collaborator leaves a shared list.
+func RemoveMember(memberCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		memberID := chi.URLParam(r, "userId")
+		if findList(user.List, listID) == -1 && memberID != user.ID {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+
+		result, err := memberCollection.DeleteOne(context.TODO(), bson.M{"list_id": listID, "user_id": memberID})
+		if err != nil {
+			log.Println("Error removing member:", err)
+			http.Error(w, "Failed to remove member", http.StatusInternalServerError)
+			return
+		}
+		if result.DeletedCount == 0 {
+			http.Error(w, "Member not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Member removed successfully",
+		})
+	}
+}
+
+func inviteURL(token string) string {
+	return config.InviteURLBase + token
+}
+
+// CreateInvite creates an invite to one of the user's lists. An invite with
+// an email can only be accepted by that user, once, and is mailed to them
+// when email is configured; one without is a shareable link anyone signed in
+// can use until it expires. The token is only returned here.
+func CreateInvite(inviteCollection *mongo.Collection, userCollection *mongo.Collection, mailer notify.Notifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		index := findList(user.List, listID)
+		if index == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		list := user.List[index]
+
+		var inviteRequest struct {
+			Email          string `json:"email"`
+			Role           string `json:"role"`
+			ExpiresInHours int    `json:"expires_in_hours"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&inviteRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if inviteRequest.Role == "" {
+			inviteRequest.Role = models.RoleEditor
+		}
+		if !ValidRole(inviteRequest.Role) {
+			http.Error(w, "Role must be editor or viewer", http.StatusBadRequest)
+			return
+		}
+
+		if inviteRequest.ExpiresInHours == 0 {
+			inviteRequest.ExpiresInHours = defaultInviteHours
+		}
+		if inviteRequest.ExpiresInHours < 1 || inviteRequest.ExpiresInHours > maxInviteHours {
+			http.Error(w, fmt.Sprintf("expires_in_hours must be between 1 and %d", maxInviteHours), http.StatusBadRequest)
+			return
+		}
+
+		inviteRequest.Email = strings.ToLower(strings.TrimSpace(inviteRequest.Email))
+		if inviteRequest.Email != "" {
+			if !strings.Contains(inviteRequest.Email, "@") {
+				http.Error(w, "Invalid email address", http.StatusBadRequest)
+				return
+			}
+			if strings.EqualFold(inviteRequest.Email, user.Email) {
+				http.Error(w, "You already own this list", http.StatusBadRequest)
+				return
+			}
+
+			count, err := config.ListMemberCollection(inviteCollection.Database()).CountDocuments(
+				context.TODO(),
+				bson.M{"list_id": listID, "email": inviteRequest.Email},
+			)
+			if err != nil {
+				log.Println("Error checking members:", err)
+				http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+				return
+			}
+			if count > 0 {
+				http.Error(w, "This user is already a member of the list", http.StatusConflict)
+				return
+			}
+		}
+
+		token, tokenHash, err := newToken()
+		if err != nil {
+			log.Println("Error generating invite token:", err)
+			http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		invite := models.ListInvite{
+			ID:        primitive.NewObjectID(),
+			ListID:    listID,
+			OwnerID:   user.ID,
+			Email:     inviteRequest.Email,
+			Role:      inviteRequest.Role,
+			TokenHash: tokenHash,
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Duration(inviteRequest.ExpiresInHours) * time.Hour),
+		}
+
+		if _, err := inviteCollection.InsertOne(context.TODO(), invite); err != nil {
+			log.Println("Error creating invite:", err)
+			http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+			return
+		}
+
+		emailed := false
+		if invite.Email != "" && mailer != nil {
+			err := mailer.Send(r.Context(), notify.Recipient{Email: invite.Email}, notify.Message{
+				Subject:  fmt.Sprintf("%s shared the list %q with you", user.Name, list.Name),
+				Body:     fmt.Sprintf("%s invited you to the list %q as %s. Open this link to join before %s:\n\n%s", user.Name, list.Name, invite.Role, invite.ExpiresAt.UTC().Format(time.RFC1123), inviteURL(token)),
+				ItemType: "list",
+				ItemID:   listID.Hex(),
+				At:       now,
+			})
+			if err != nil {
+				log.Println("Error emailing invite:", err)
+			}
+			emailed = err == nil
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Invite created successfully",
+			"invite":  invite,
+			"token":   token,
+			"url":     inviteURL(token),
+			"emailed": emailed,
+		})
+	}
+}
+
+func GetInvites(inviteCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		if findList(user.List, listID) == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+
+		cursor, err := inviteCollection.Find(
+			context.TODO(),
+			bson.M{"list_id": listID, "expires_at": bson.M{"$gt": time.Now()}},
+			options.Find().SetSort(bson.M{"_id": 1}),
+		)
+		if err != nil {
+			log.Println("Error fetching invites:", err)
+			http.Error(w, "Failed to fetch invites", http.StatusInternalServerError)
+			return
+		}
+
+		invites := make([]models.ListInvite, 0)
+		if err := cursor.All(context.TODO(), &invites); err != nil {
+			log.Println("Error decoding invites:", err)
+			http.Error(w, "Failed to fetch invites", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(invites)
+	}
+}
+
+func DeleteInvite(inviteCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		inviteID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "inviteId"))
+		if err != nil {
+			http.Error(w, "Invalid invite ID", http.StatusBadRequest)
+			return
+		}
+
+		if findList(user.List, listID) == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+
+		result, err := inviteCollection.DeleteOne(context.TODO(), bson.M{"_id": inviteID, "list_id": listID})
+		if err != nil {
+			log.Println("Error deleting invite:", err)
+			http.Error(w, "Failed to revoke invite", http.StatusInternalServerError)
+			return
+		}
+		if result.DeletedCount == 0 {
+			http.Error(w, "Invite not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Invite revoked successfully",
+		})
+	}
+}
+
+// findInvite looks up the invite behind a token and the list it is for. It
+// writes the error response itself when the invite cannot be used by user.
+func findInvite(w http.ResponseWriter, inviteCollection *mongo.Collection, userCollection *mongo.Collection, user models.User, token string) (models.ListInvite, models.User, models.List, bool) {
+	var invite models.ListInvite
+	err := inviteCollection.FindOne(context.TODO(), bson.M{"token_hash": hashToken(token)}).Decode(&invite)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return invite, models.User{}, models.List{}, false
+	}
+	if err != nil {
+		log.Println("Error fetching invite:", err)
+		http.Error(w, "Failed to fetch invite", http.StatusInternalServerError)
+		return invite, models.User{}, models.List{}, false
+	}
+
+	if !invite.ExpiresAt.After(time.Now()) {
+		http.Error(w, "Invite has expired", http.StatusGone)
+		return invite, models.User{}, models.List{}, false
+	}
+	if invite.Email != "" && !strings.EqualFold(invite.Email, user.Email) {
+		http.Error(w, "This invite was sent to a different email address", http.StatusForbidden)
+		return invite, models.User{}, models.List{}, false
+	}
+
+	var owner models.User
+	err = userCollection.FindOne(context.TODO(), bson.M{"_id": invite.OwnerID}).Decode(&owner)
+	index := -1
+	if err == nil {
+		index = findList(owner.List, invite.ListID)
+	}
+	if index == -1 {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return invite, models.User{}, models.List{}, false
+	}
+	return invite, owner, owner.List[index], true
+}
+
+func GetInvite(inviteCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		invite, owner, list, ok := findInvite(w, inviteCollection, userCollection, user, chi.URLParam(r, "token"))
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"list_id":    list.ID,
+			"list_name":  list.Name,
+			"list_color": list.Color,
+			"owner":      models.Mention{UserID: owner.ID, Name: owner.Name, Email: owner.Email},
+			"role":       invite.Role,
+			"expires_at": invite.ExpiresAt,
+		})
+	}
+}
+
+// AcceptInvite adds the user to the invited list. Someone who is already a
+// member keeps their current role, so following an old viewer link cannot
+// demote an editor.
+func AcceptInvite(inviteCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		invite, owner, list, ok := findInvite(w, inviteCollection, userCollection, user, chi.URLParam(r, "token"))
+		if !ok {
+			return
+		}
+
+		if owner.ID == user.ID {
+			http.Error(w, "You already own this list", http.StatusBadRequest)
+			return
+		}
+
+		var member models.ListMember
+		err = config.ListMemberCollection(inviteCollection.Database()).FindOneAndUpdate(
+			context.TODO(),
+			bson.M{"list_id": list.ID, "user_id": user.ID},
+			bson.M{
+				"$set": bson.M{
+					"owner_id": owner.ID,
+					"name":     user.Name,
+					"email":    strings.ToLower(user.Email),
+					"picture":  user.Picture,
+				},
+				"$setOnInsert": bson.M{
+					"_id":       primitive.NewObjectID(),
+					"role":      invite.Role,
+					"joined_at": time.Now(),
+				},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&member)
+		if err != nil {
+			log.Println("Error adding member:", err)
+			http.Error(w, "Failed to accept invite", http.StatusInternalServerError)
+			return
+		}
+
+		if invite.Email != "" {
+			if _, err := inviteCollection.DeleteOne(context.TODO(), bson.M{"_id": invite.ID}); err != nil {
+				log.Println("Error removing used invite:", err)
+			}
+		}
+
+		list.Role = member.Role
+		list.OwnerID = owner.ID
+		list.OwnerName = owner.Name
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Invite accepted successfully",
+			"list":    list,
+			"member":  member,
+		})
+	}
+}
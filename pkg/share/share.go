@@ -0,0 +1,280 @@
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned when the list or todo does not exist or is not
+// shared with the user, so callers cannot tell the two apart.
+var ErrNotFound = errors.New("not found")
+
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := config.ListMemberCollection(db).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "list_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = config.ListInviteCollection(db).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "list_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func ValidRole(role string) bool {
+	return role == models.RoleEditor || role == models.RoleViewer
+}
+
+// CanEdit reports whether the role may change the todos of a list. Viewers
+// can only read them.
+func CanEdit(role string) bool {
+	return role == models.RoleOwner || role == models.RoleEditor
+}
+
+func newToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashToken(token), nil
+}
+
+// hashToken is what gets stored for an invite, so a leaked database does not
+// leak working invite links.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func findList(lists []models.List, id primitive.ObjectID) int {
+	for i, list := range lists {
+		if list.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func memberships(ctx context.Context, db *mongo.Database, userID string) ([]models.ListMember, error) {
+	cursor, err := config.ListMemberCollection(db).Find(
+		ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]models.ListMember, 0)
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// Members returns the collaborators of a list, not counting its owner.
+func Members(ctx context.Context, db *mongo.Database, listID primitive.ObjectID) ([]models.ListMember, error) {
+	cursor, err := config.ListMemberCollection(db).Find(
+		ctx,
+		bson.M{"list_id": listID},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]models.ListMember, 0)
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ListAccount returns the user document that holds the list, which is the
+// one todo updates in the list have to be written to, together with the
+// user's role on it. The inbox and the user's own lists resolve to the user
+// themselves.
+func ListAccount(ctx context.Context, userCollection *mongo.Collection, user models.User, listID primitive.ObjectID) (models.User, string, error) {
+	if listID.IsZero() || findList(user.List, listID) != -1 {
+		return user, models.RoleOwner, nil
+	}
+
+	var member models.ListMember
+	err := config.ListMemberCollection(userCollection.Database()).FindOne(ctx, bson.M{"list_id": listID, "user_id": user.ID}).Decode(&member)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.User{}, "", ErrNotFound
+	}
+	if err != nil {
+		return models.User{}, "", err
+	}
+
+	var owner models.User
+	err = userCollection.FindOne(ctx, bson.M{"_id": member.OwnerID, "list._id": listID}).Decode(&owner)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.User{}, "", ErrNotFound
+	}
+	if err != nil {
+		return models.User{}, "", err
+	}
+	return owner, member.Role, nil
+}
+
+// TodoAccount is ListAccount for a todo: it looks in the user's own
+// document first and then in the documents of the owners of the lists
+// shared with them. A todo of a shared owner is only reachable while it sits
+// in a list the user is a member of.
+func TodoAccount(ctx context.Context, userCollection *mongo.Collection, user models.User, todoID primitive.ObjectID) (models.User, string, error) {
+	for _, todo := range user.Todo {
+		if todo.ID == todoID {
+			return user, models.RoleOwner, nil
+		}
+	}
+
+	members, err := memberships(ctx, userCollection.Database(), user.ID)
+	if err != nil {
+		return models.User{}, "", err
+	}
+	if len(members) == 0 {
+		return models.User{}, "", ErrNotFound
+	}
+
+	owners := make([]string, 0, len(members))
+	for _, member := range members {
+		owners = append(owners, member.OwnerID)
+	}
+
+	var owner models.User
+	err = userCollection.FindOne(ctx, bson.M{"_id": bson.M{"$in": owners}, "todos._id": todoID}).Decode(&owner)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.User{}, "", ErrNotFound
+	}
+	if err != nil {
+		return models.User{}, "", err
+	}
+
+	for _, todo := range owner.Todo {
+		if todo.ID != todoID {
+			continue
+		}
+		for _, member := range members {
+			if member.OwnerID == owner.ID && member.ListID == todo.ListID {
+				return owner, member.Role, nil
+			}
+		}
+	}
+	return models.User{}, "", ErrNotFound
+}
+
+// sharedAccounts loads the documents of the users who share lists with the
+// user, in the order the user joined them, with their lists cut down to the
+// shared ones and marked with the user's role.
+func sharedAccounts(ctx context.Context, userCollection *mongo.Collection, user models.User, projection interface{}) ([]models.User, error) {
+	members, err := memberships(ctx, userCollection.Database(), user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	ownerIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		ownerIDs = append(ownerIDs, member.OwnerID)
+	}
+
+	find := options.Find()
+	if projection != nil {
+		find.SetProjection(projection)
+	}
+	cursor, err := userCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ownerIDs}}, find)
+	if err != nil {
+		return nil, err
+	}
+
+	var owners []models.User
+	if err := cursor.All(ctx, &owners); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.User, len(owners))
+	for i := range owners {
+		byID[owners[i].ID] = &owners[i]
+	}
+
+	shared := make(map[string][]models.List)
+	var order []string
+	for _, member := range members {
+		owner, ok := byID[member.OwnerID]
+		if !ok {
+			continue
+		}
+		index := findList(owner.List, member.ListID)
+		if index == -1 {
+			continue
+		}
+		list := owner.List[index]
+		list.Role = member.Role
+		list.OwnerID = owner.ID
+		list.OwnerName = owner.Name
+		if _, seen := shared[owner.ID]; !seen {
+			order = append(order, owner.ID)
+		}
+		shared[owner.ID] = append(shared[owner.ID], list)
+	}
+
+	accounts := make([]models.User, 0, len(order))
+	for _, id := range order {
+		account := *byID[id]
+		account.List = shared[id]
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// SharedAccounts returns the documents of the users who share lists with
+// the user. Their List only holds the shared lists, but Todo is complete, so
+// callers have to pick the todos of those lists themselves.
+func SharedAccounts(ctx context.Context, userCollection *mongo.Collection, user models.User) ([]models.User, error) {
+	return sharedAccounts(ctx, userCollection, user, nil)
+}
+
+// SharedLists returns the lists other users have shared with the user,
+// marked with the user's role and the owner.
+func SharedLists(ctx context.Context, userCollection *mongo.Collection, user models.User) ([]models.List, error) {
+	accounts, err := sharedAccounts(ctx, userCollection, user, bson.M{"username": 1, "list": 1})
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]models.List, 0)
+	for _, account := range accounts {
+		lists = append(lists, account.List...)
+	}
+	return lists, nil
+}
+
+// RemoveList drops the members and pending invites of a deleted list.
+func RemoveList(ctx context.Context, db *mongo.Database, listID primitive.ObjectID) error {
+	if _, err := config.ListMemberCollection(db).DeleteMany(ctx, bson.M{"list_id": listID}); err != nil {
+		return err
+	}
+	_, err := config.ListInviteCollection(db).DeleteMany(ctx, bson.M{"list_id": listID})
+	return err
+}
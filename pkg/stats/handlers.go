@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultRangeDays = 30
+	maxRangeDays     = 731
+)
+
+func GetStats(userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		values := r.URL.Query()
+
+		loc := utils.LoadLocation(user.TimeZone)
+		if tz := values.Get("tz"); tz != "" {
+			if loc, err = time.LoadLocation(tz); err != nil {
+				http.Error(w, "Invalid timezone", http.StatusBadRequest)
+				return
+			}
+		}
+
+		interval := values.Get("interval")
+		switch interval {
+		case "":
+			interval = IntervalDay
+		case IntervalDay, IntervalWeek:
+		default:
+			http.Error(w, "interval must be day or week", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		to := utils.StartOfDay(now, loc)
+		if value := values.Get("to"); value != "" {
+			if to, err = time.ParseInLocation(dateLayout, value, loc); err != nil {
+				http.Error(w, "to must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+		}
+		from := to.AddDate(0, 0, -(defaultRangeDays - 1))
+		if value := values.Get("from"); value != "" {
+			if from, err = time.ParseInLocation(dateLayout, value, loc); err != nil {
+				http.Error(w, "from must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+		}
+
+		end := to.AddDate(0, 0, 1)
+		if from.After(to) || from.AddDate(0, 0, maxRangeDays).Before(end) {
+			http.Error(w, fmt.Sprintf("from must not be after to, and the range can span at most %d days", maxRangeDays), http.StatusBadRequest)
+			return
+		}
+
+		report := Compute(user, from, end, loc, interval, now)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(report)
+		if err != nil {
+			log.Println("Error encoding stats:", err)
+			http.Error(w, "Failed to compute stats", http.StatusInternalServerError)
+			return
+		}
+	}
+}
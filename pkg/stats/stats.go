@@ -0,0 +1,290 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const dateLayout = "2006-01-02"
+
+const (
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+const busiestDatesLimit = 5
+
+type Period struct {
+	Start     string `json:"start"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	Events    int    `json:"events"`
+}
+
+type ListStats struct {
+	ListID         primitive.ObjectID `json:"list_id"`
+	Name           string             `json:"name"`
+	Created        int                `json:"created"`
+	Completed      int                `json:"completed"`
+	Open           int                `json:"open"`
+	Overdue        int                `json:"overdue"`
+	CompletionRate float64            `json:"completion_rate"`
+}
+
+type LeadTime struct {
+	Samples      int     `json:"samples"`
+	AverageHours float64 `json:"average_hours"`
+	MedianHours  float64 `json:"median_hours"`
+	FastestHours float64 `json:"fastest_hours"`
+	SlowestHours float64 `json:"slowest_hours"`
+}
+
+type Weekday struct {
+	Weekday   string `json:"weekday"`
+	Completed int    `json:"completed"`
+	Events    int    `json:"events"`
+}
+
+type BusyDate struct {
+	Date      string `json:"date"`
+	Completed int    `json:"completed"`
+	Events    int    `json:"events"`
+	Total     int    `json:"total"`
+}
+
+type Totals struct {
+	Created        int     `json:"created"`
+	Completed      int     `json:"completed"`
+	CompletionRate float64 `json:"completion_rate"`
+	Open           int     `json:"open"`
+	Overdue        int     `json:"overdue"`
+	Events         int     `json:"events"`
+}
+
+type Report struct {
+	From         string      `json:"from"`
+	To           string      `json:"to"`
+	TimeZone     string      `json:"timezone"`
+	Interval     string      `json:"interval"`
+	Totals       Totals      `json:"totals"`
+	Series       []Period    `json:"series"`
+	Lists        []ListStats `json:"lists"`
+	LeadTime     LeadTime    `json:"lead_time"`
+	Weekdays     []Weekday   `json:"weekdays"`
+	BusiestDates []BusyDate  `json:"busiest_dates"`
+}
+
+func overdue(todo models.Todo, user models.User, now time.Time) bool {
+	if todo.Done || todo.DueDate == nil {
+		return false
+	}
+	if todo.AllDay {
+		loc := utils.LoadLocation(user.TimeZone)
+		if todo.TimeZone != "" {
+			loc = utils.LoadLocation(todo.TimeZone)
+		}
+		return todo.DueDate.Before(utils.StartOfDay(now, loc))
+	}
+	return todo.DueDate.Before(now)
+}
+
+func periodStart(t time.Time, loc *time.Location, interval string) time.Time {
+	day := utils.StartOfDay(t, loc)
+	if interval == IntervalWeek {
+		day = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+func rate(done int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(done) / float64(total)
+}
+
+func eventTime(event models.Event) time.Time {
+	if event.Start.IsZero() {
+		return event.Date
+	}
+	return event.Start
+}
+
+// Compute builds the report for [from, to) in loc. A todo counts as created
+// when its ID was minted and as completed at CompletedAt; todos finished
+// before completion times were recorded are left out of the completion
+// figures. Per-list completion rate is the share of the todos created in the
+// range that are done by now, so lists of different sizes compare fairly.
+// Open and overdue counts describe the todos as they stand at now.
+func Compute(user models.User, from time.Time, to time.Time, loc *time.Location, interval string, now time.Time) Report {
+	report := Report{
+		From:     from.Format(dateLayout),
+		To:       to.AddDate(0, 0, -1).Format(dateLayout),
+		TimeZone: loc.String(),
+		Interval: interval,
+		Series:   make([]Period, 0),
+		Lists:    make([]ListStats, 0),
+	}
+
+	periods := make(map[string]int)
+	for start := periodStart(from, loc, interval); start.Before(to); {
+		key := start.Format(dateLayout)
+		periods[key] = len(report.Series)
+		report.Series = append(report.Series, Period{Start: key})
+		if interval == IntervalWeek {
+			start = start.AddDate(0, 0, 7)
+		} else {
+			start = start.AddDate(0, 0, 1)
+		}
+	}
+	bucket := func(t time.Time) *Period {
+		if t.Before(from) || !t.Before(to) {
+			return nil
+		}
+		if i, ok := periods[periodStart(t, loc, interval).Format(dateLayout)]; ok {
+			return &report.Series[i]
+		}
+		return nil
+	}
+
+	lists := make(map[primitive.ObjectID]*ListStats)
+	var listOrder []primitive.ObjectID
+	listFor := func(id primitive.ObjectID) *ListStats {
+		if stats, ok := lists[id]; ok {
+			return stats
+		}
+		stats := &ListStats{ListID: id, Name: "Inbox"}
+		for _, list := range user.List {
+			if list.ID == id {
+				stats.Name = list.Name
+			}
+		}
+		lists[id] = stats
+		listOrder = append(listOrder, id)
+		return stats
+	}
+	for _, list := range user.List {
+		listFor(list.ID)
+	}
+
+	weekdays := make([]Weekday, 7)
+	for i := range weekdays {
+		weekdays[i].Weekday = time.Weekday((i + 1) % 7).String()
+	}
+	dates := make(map[string]*BusyDate)
+	busy := func(t time.Time) (*Weekday, *BusyDate) {
+		local := t.In(loc)
+		key := local.Format(dateLayout)
+		if dates[key] == nil {
+			dates[key] = &BusyDate{Date: key}
+		}
+		return &weekdays[(int(local.Weekday())+6)%7], dates[key]
+	}
+
+	createdDone := 0
+	listCreatedDone := make(map[primitive.ObjectID]int)
+	var leadTimes []float64
+	for _, todo := range user.Todo {
+		list := listFor(todo.ListID)
+
+		if !todo.Done {
+			report.Totals.Open++
+			list.Open++
+		}
+		if overdue(todo, user, now) {
+			report.Totals.Overdue++
+			list.Overdue++
+		}
+
+		created := todo.ID.Timestamp()
+		if period := bucket(created); period != nil {
+			period.Created++
+			report.Totals.Created++
+			list.Created++
+			if todo.Done {
+				createdDone++
+				listCreatedDone[todo.ListID]++
+			}
+		}
+
+		if todo.CompletedAt == nil {
+			continue
+		}
+		if period := bucket(*todo.CompletedAt); period != nil {
+			period.Completed++
+			report.Totals.Completed++
+			list.Completed++
+
+			weekday, date := busy(*todo.CompletedAt)
+			weekday.Completed++
+			date.Completed++
+
+			if lead := todo.CompletedAt.Sub(created).Hours(); lead >= 0 {
+				leadTimes = append(leadTimes, lead)
+			}
+		}
+	}
+
+	for _, event := range user.Event {
+		at := eventTime(event)
+		if period := bucket(at); period != nil {
+			period.Events++
+			report.Totals.Events++
+
+			weekday, date := busy(at)
+			weekday.Events++
+			date.Events++
+		}
+	}
+
+	report.Totals.CompletionRate = rate(createdDone, report.Totals.Created)
+
+	for _, id := range listOrder {
+		list := lists[id]
+		list.CompletionRate = rate(listCreatedDone[id], list.Created)
+		report.Lists = append(report.Lists, *list)
+	}
+	sort.SliceStable(report.Lists, func(i, j int) bool { return report.Lists[i].Completed > report.Lists[j].Completed })
+
+	if len(leadTimes) > 0 {
+		sort.Float64s(leadTimes)
+		sum := 0.0
+		for _, lead := range leadTimes {
+			sum += lead
+		}
+		median := leadTimes[len(leadTimes)/2]
+		if len(leadTimes)%2 == 0 {
+			median = (leadTimes[len(leadTimes)/2-1] + median) / 2
+		}
+		report.LeadTime = LeadTime{
+			Samples:      len(leadTimes),
+			AverageHours: sum / float64(len(leadTimes)),
+			MedianHours:  median,
+			FastestHours: leadTimes[0],
+			SlowestHours: leadTimes[len(leadTimes)-1],
+		}
+	}
+
+	report.Weekdays = weekdays
+
+	report.BusiestDates = make([]BusyDate, 0, len(dates))
+	for _, date := range dates {
+		date.Total = date.Completed + date.Events
+		report.BusiestDates = append(report.BusiestDates, *date)
+	}
+	sort.Slice(report.BusiestDates, func(i, j int) bool {
+		if report.BusiestDates[i].Total != report.BusiestDates[j].Total {
+			return report.BusiestDates[i].Total > report.BusiestDates[j].Total
+		}
+		return report.BusiestDates[i].Date < report.BusiestDates[j].Date
+	})
+	if len(report.BusiestDates) > busiestDatesLimit {
+		report.BusiestDates = report.BusiestDates[:busiestDatesLimit]
+	}
+
+	return report
+}
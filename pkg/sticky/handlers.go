@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
 	"github.com/userAdityaa/todo-backend/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -23,6 +24,7 @@ func CreateSticky(stickyCollection *mongo.Collection, userCollection *mongo.Coll
 		}
 
 		sticky.ID = primitive.NewObjectID()
+		sticky.Attachments = nil
 
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -60,6 +62,9 @@ func CreateSticky(stickyCollection *mongo.Collection, userCollection *mongo.Coll
 			return
 		}
 
+		recorder := activity.NewRecorder(stickyCollection.Database(), user.ID, user.ID)
+		recorder.Track(activity.ItemSticky, sticky.ID)
+
 		_, err = stickyCollection.InsertOne(context.TODO(), sticky)
 		if err != nil {
 			log.Println("Error inserting sticky:", err)
@@ -82,6 +87,7 @@ func CreateSticky(stickyCollection *mongo.Collection, userCollection *mongo.Coll
 			http.Error(w, "Failed to update user with new Sticky", http.StatusInternalServerError)
 			return
 		}
+		recorder.Commit()
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -204,6 +210,9 @@ func UpdateSticky(stickyCollection *mongo.Collection, userCollection *mongo.Coll
 			return
 		}
 
+		recorder := activity.NewRecorder(stickyCollection.Database(), user.ID, user.ID)
+		recorder.Track(activity.ItemSticky, partialUpdate.ID)
+
 		result, err := stickyCollection.UpdateOne(
 			context.TODO(),
 			bson.M{
@@ -236,6 +245,8 @@ func UpdateSticky(stickyCollection *mongo.Collection, userCollection *mongo.Coll
 			return
 		}
 
+		recorder.Commit()
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "Sticky updated successfully",
@@ -288,6 +299,9 @@ func DeleteSticky(stickyCollection *mongo.Collection, userCollection *mongo.Coll
 			return
 		}
 
+		recorder := activity.NewRecorder(stickyCollection.Database(), user.ID, user.ID)
+		recorder.Track(activity.ItemSticky, deleteRequest.ID)
+
 		result, err := stickyCollection.DeleteOne(
 			context.TODO(),
 			bson.M{"_id": deleteRequest.ID},
@@ -316,6 +330,8 @@ func DeleteSticky(stickyCollection *mongo.Collection, userCollection *mongo.Coll
 			return
 		}
 
+		recorder.Commit()
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"message": "Sticky deleted successfully",
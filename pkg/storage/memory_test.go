@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+
+	if _, err := m.Get(ctx, "user/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing key = %v, want ErrNotFound", err)
+	}
+
+	for _, body := range []string{"first", "second"} {
+		if err := m.Put(ctx, "user/a.txt", strings.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		reader, err := m.Get(ctx, "user/a.txt")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		got, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(got) != body {
+			t.Errorf("Get = %q, want %q", got, body)
+		}
+	}
+
+	if err := m.Delete(ctx, "user/a.txt"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := m.Get(ctx, "user/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := m.Delete(ctx, "user/a.txt"); err != nil {
+		t.Errorf("Delete of a missing key = %v, want nil", err)
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"user/a.txt", true},
+		{"a", true},
+		{"user/.hidden", true},
+		{"", false},
+		{"/abs", false},
+		{"user//a", false},
+		{"user/", false},
+		{"user/./a", false},
+		{"../escape", false},
+		{"user/../../escape", false},
+		{`user\a`, false},
+	}
+	m := NewMemory()
+	for _, tt := range tests {
+		if err := validKey(tt.key); (err == nil) != tt.valid {
+			t.Errorf("validKey(%q) = %v, want valid %v", tt.key, err, tt.valid)
+		}
+		if err := m.Put(context.Background(), tt.key, strings.NewReader(""), 0, ""); (err == nil) != tt.valid {
+			t.Errorf("Memory.Put(%q) = %v, want valid %v", tt.key, err, tt.valid)
+		}
+	}
+}
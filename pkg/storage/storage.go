@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/userAdityaa/todo-backend/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs under opaque keys. Keys are slash separated and never
+// start with a slash or contain "..".
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
+
+func FromConfig() (Store, error) {
+	switch config.StorageBackend {
+	case "", "local":
+		return NewLocal(config.StorageLocalDir)
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:        config.S3Endpoint,
+			Region:          config.S3Region,
+			Bucket:          config.S3Bucket,
+			AccessKeyID:     config.S3AccessKeyID,
+			SecretAccessKey: config.S3SecretAccessKey,
+			ForcePathStyle:  config.S3ForcePathStyle,
+		})
+	case "memory":
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf("unknown STORAGE_BACKEND %q, expected local, s3 or memory", config.StorageBackend)
+}
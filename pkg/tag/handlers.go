@@ -0,0 +1,497 @@
+package tag
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func findTag(tags []models.Tag, name string) int {
+	for i, tag := range tags {
+		if tag.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func replaceTags(tags []string, from map[string]bool, into string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	replaced := make([]string, 0, len(tags))
+	for _, name := range tags {
+		if from[name] {
+			if into == "" {
+				continue
+			}
+			name = into
+		}
+		replaced = append(replaced, name)
+	}
+	return Normalize(replaced)
+}
+
+func sameTags(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rewriteTodoTags moves the todos tagged with any of from over to into, or
+// just drops those tags when into is empty, and saves the registry. The
+// arrays are changed in place rather than written back from the snapshot so
+// that todos created or edited meanwhile are kept.
+func rewriteTodoTags(todoCollection *mongo.Collection, userCollection *mongo.Collection, user models.User, registry []models.Tag, from map[string]bool, into string) (int, error) {
+	names := make([]string, 0, len(from))
+	for name := range from {
+		names = append(names, name)
+	}
+
+	affected := 0
+	ids := make([]primitive.ObjectID, 0)
+	for _, todo := range user.Todo {
+		changed := !sameTags(replaceTags(todo.Tags, from, into), todo.Tags)
+		if todo.Recurrence != nil && todo.Recurrence.Template != nil {
+			template := todo.Recurrence.Template
+			changed = changed || !sameTags(replaceTags(template.Tags, from, into), template.Tags)
+		}
+		if changed {
+			affected++
+			ids = append(ids, todo.ID)
+		}
+	}
+
+	recorder := activity.NewRecorder(todoCollection.Database(), user.ID, user.ID)
+	recorder.Track(activity.ItemTodo, ids...)
+
+	// The target tag is added before the old ones are pulled, since the
+	// array filters pick the todos by the old tags.
+	filters := options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+		bson.M{"t.tags": bson.M{"$in": names}},
+		bson.M{"s.recurrence.template.tags": bson.M{"$in": names}},
+	}})
+	userUpdates := []bson.M{{"$pull": bson.M{
+		"todos.$[t].tags":                     bson.M{"$in": names},
+		"todos.$[s].recurrence.template.tags": bson.M{"$in": names},
+	}}}
+	if into != "" {
+		userUpdates = append([]bson.M{{"$addToSet": bson.M{
+			"todos.$[t].tags":                     into,
+			"todos.$[s].recurrence.template.tags": into,
+		}}}, userUpdates...)
+	}
+	for _, update := range userUpdates {
+		if _, err := userCollection.UpdateOne(context.TODO(), bson.M{"_id": user.ID}, update, filters); err != nil {
+			return affected, err
+		}
+	}
+
+	if len(ids) > 0 {
+		for _, field := range []string{"tags", "recurrence.template.tags"} {
+			filter := bson.M{"_id": bson.M{"$in": ids}, field: bson.M{"$in": names}}
+			if into != "" {
+				if _, err := todoCollection.UpdateMany(context.TODO(), filter, bson.M{"$addToSet": bson.M{field: into}}); err != nil {
+					return affected, err
+				}
+			}
+			if _, err := todoCollection.UpdateMany(context.TODO(), filter, bson.M{"$pull": bson.M{field: bson.M{"$in": names}}}); err != nil {
+				return affected, err
+			}
+		}
+	}
+
+	recorder.Commit()
+
+	_, err := userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{"tags": registry}},
+	)
+	return affected, err
+}
+
+func GetAllTags(todoCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		counts := make(map[string]int)
+		for _, todo := range user.Todo {
+			for _, name := range todo.Tags {
+				counts[name]++
+			}
+		}
+
+		type tagUsage struct {
+			models.Tag
+			Count int `json:"count"`
+		}
+
+		tags := make([]tagUsage, 0, len(user.Tags))
+		for _, tag := range user.Tags {
+			tags = append(tags, tagUsage{Tag: tag, Count: counts[tag.Name]})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(tags)
+		if err != nil {
+			log.Println("Error encoding tags:", err)
+			http.Error(w, "Failed to fetch tags", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func CreateTag(todoCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var newTag models.Tag
+		if err := json.NewDecoder(r.Body).Decode(&newTag); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		newTag.Name = NormalizeName(newTag.Name)
+		if newTag.Name == "" {
+			http.Error(w, "Name is a required field", http.StatusBadRequest)
+			return
+		}
+
+		if findTag(user.Tags, newTag.Name) != -1 {
+			http.Error(w, "Tag already exists", http.StatusConflict)
+			return
+		}
+
+		user.Tags = append(user.Tags, newTag)
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$set": bson.M{"tags": user.Tags}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to create tag", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Tag Created Successfully",
+			"tag":     newTag,
+		})
+	}
+}
+
+func UpdateTag(todoCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		name := NormalizeName(chi.URLParam(r, "name"))
+		index := findTag(user.Tags, name)
+		if index == -1 {
+			http.Error(w, "Tag not found", http.StatusNotFound)
+			return
+		}
+
+		var partialUpdate struct {
+			Name  *string `json:"name,omitempty"`
+			Color *string `json:"color,omitempty"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&partialUpdate); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if partialUpdate.Color != nil {
+			user.Tags[index].Color = *partialUpdate.Color
+		}
+
+		affected := 0
+		if partialUpdate.Name != nil && NormalizeName(*partialUpdate.Name) != name {
+			newName := NormalizeName(*partialUpdate.Name)
+			if newName == "" {
+				http.Error(w, "Name cannot be empty", http.StatusBadRequest)
+				return
+			}
+			if findTag(user.Tags, newName) != -1 {
+				http.Error(w, "A tag with that name already exists, merge it instead", http.StatusConflict)
+				return
+			}
+			user.Tags[index].Name = newName
+
+			affected, err = rewriteTodoTags(todoCollection, userCollection, user, user.Tags, map[string]bool{name: true}, newName)
+			if err != nil {
+				log.Println("Error renaming tag:", err)
+				http.Error(w, "Failed to rename tag", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			_, err = userCollection.UpdateOne(
+				context.TODO(),
+				bson.M{"_id": user.ID},
+				bson.M{"$set": bson.M{"tags": user.Tags}},
+			)
+			if err != nil {
+				http.Error(w, "Failed to update tag", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Tag updated successfully",
+			"tag":      user.Tags[index],
+			"affected": affected,
+		})
+	}
+}
+
+func MergeTags(todoCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var mergeRequest struct {
+			From []string `json:"from"`
+			Into string   `json:"into"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&mergeRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		into := NormalizeName(mergeRequest.Into)
+		if into == "" || len(mergeRequest.From) == 0 {
+			http.Error(w, "From and Into are required fields", http.StatusBadRequest)
+			return
+		}
+
+		from := make(map[string]bool)
+		for _, name := range Normalize(mergeRequest.From) {
+			if name == into {
+				continue
+			}
+			if findTag(user.Tags, name) == -1 {
+				http.Error(w, "Tag not found: "+name, http.StatusNotFound)
+				return
+			}
+			from[name] = true
+		}
+
+		registry := make([]models.Tag, 0, len(user.Tags))
+		for _, tag := range user.Tags {
+			if !from[tag.Name] {
+				registry = append(registry, tag)
+			}
+		}
+		if findTag(registry, into) == -1 {
+			registry = append(registry, models.Tag{Name: into})
+		}
+
+		affected, err := rewriteTodoTags(todoCollection, userCollection, user, registry, from, into)
+		if err != nil {
+			log.Println("Error merging tags:", err)
+			http.Error(w, "Failed to merge tags", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Tags merged successfully",
+			"into":     into,
+			"affected": affected,
+		})
+	}
+}
+
+func DeleteTag(todoCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		name := NormalizeName(chi.URLParam(r, "name"))
+		index := findTag(user.Tags, name)
+		if index == -1 {
+			http.Error(w, "Tag not found", http.StatusNotFound)
+			return
+		}
+
+		registry := append(user.Tags[:index:index], user.Tags[index+1:]...)
+		affected, err := rewriteTodoTags(todoCollection, userCollection, user, registry, map[string]bool{name: true}, "")
+		if err != nil {
+			log.Println("Error deleting tag:", err)
+			http.Error(w, "Failed to delete tag", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Tag deleted successfully",
+			"affected": affected,
+		})
+	}
+}
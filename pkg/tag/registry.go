@@ -0,0 +1,58 @@
+package tag
+
+import (
+	"context"
+	"strings"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+}
+
+func Normalize(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, name := range tags {
+		name = NormalizeName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+func Register(userCollection *mongo.Collection, user models.User, tags []string) error {
+	known := make(map[string]bool)
+	for _, tag := range user.Tags {
+		known[tag.Name] = true
+	}
+
+	registry := user.Tags
+	for _, name := range tags {
+		if !known[name] {
+			known[name] = true
+			registry = append(registry, models.Tag{Name: name})
+		}
+	}
+
+	if len(registry) == len(user.Tags) {
+		return nil
+	}
+
+	_, err := userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{"tags": registry}},
+	)
+	return err
+}
@@ -0,0 +1,654 @@
+package timetrack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type entryRequest struct {
+	Start           *time.Time          `json:"start"`
+	End             *time.Time          `json:"end"`
+	DurationMinutes int                 `json:"duration_minutes"`
+	Note            *string             `json:"note"`
+	TodoID          *primitive.ObjectID `json:"todo_id,omitempty"`
+}
+
+func GetTimer(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		entry, err := runningEntry(context.TODO(), collection, user.ID)
+		if err != nil {
+			log.Println("Error fetching running timer:", err)
+			http.Error(w, "Failed to fetch timer", http.StatusInternalServerError)
+			return
+		}
+		if entry != nil {
+			entry.DurationSeconds = duration(*entry, time.Now())
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"running": entry != nil,
+			"entry":   entry,
+		})
+	}
+}
+
+func StartTimer(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+			return
+		}
+
+		if _, ok := todoAccess(w, userCollection, user, todoID, http.StatusNotFound); !ok {
+			return
+		}
+
+		var request struct {
+			Note string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		entry, stopped, err := Start(context.TODO(), collection, user.ID, todoID, request.Note, time.Now())
+		if errors.Is(err, ErrTimerRunning) {
+			http.Error(w, "A timer was started at the same time, try again", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Println("Error starting timer:", err)
+			http.Error(w, "Failed to start timer", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Timer started successfully",
+			"entry":   entry,
+			"stopped": stopped,
+		})
+	}
+}
+
+func StopTimer(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		entry, err := Stop(context.TODO(), collection, user.ID, time.Now())
+		if err != nil {
+			log.Println("Error stopping timer:", err)
+			http.Error(w, "Failed to stop timer", http.StatusInternalServerError)
+			return
+		}
+		if entry == nil {
+			http.Error(w, "No timer is running", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Timer stopped successfully",
+			"entry":   entry,
+		})
+	}
+}
+
+func GetTodoTimeEntries(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+			return
+		}
+
+		todo, ok := todoAccess(w, userCollection, user, todoID, http.StatusNotFound)
+		if !ok {
+			return
+		}
+
+		cursor, err := collection.Find(
+			context.TODO(),
+			bson.M{"user_id": user.ID, "todo_id": todoID},
+			options.Find().SetSort(bson.M{"start": -1}),
+		)
+		if err != nil {
+			http.Error(w, "Failed to fetch time entries", http.StatusInternalServerError)
+			return
+		}
+
+		entries := make([]models.TimeEntry, 0)
+		if err := cursor.All(context.TODO(), &entries); err != nil {
+			http.Error(w, "Failed to fetch time entries", http.StatusInternalServerError)
+			return
+		}
+
+		var total int64
+		for _, entry := range withElapsed(entries, time.Now()) {
+			total += entry.DurationSeconds
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"entries":          entries,
+			"total_seconds":    total,
+			"estimate_minutes": todo.Estimate,
+		})
+	}
+}
+
+func CreateTimeEntry(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+			return
+		}
+
+		if _, ok := todoAccess(w, userCollection, user, todoID, http.StatusNotFound); !ok {
+			return
+		}
+
+		var request entryRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if request.Start == nil {
+			http.Error(w, "start is required", http.StatusBadRequest)
+			return
+		}
+		if request.End == nil && request.DurationMinutes > 0 {
+			end := request.Start.Add(time.Duration(request.DurationMinutes) * time.Minute)
+			request.End = &end
+		}
+		if request.End == nil || !request.End.After(*request.Start) {
+			http.Error(w, "end or duration_minutes is required and must come after start", http.StatusBadRequest)
+			return
+		}
+
+		entry := models.TimeEntry{
+			ID:              primitive.NewObjectID(),
+			UserID:          user.ID,
+			TodoID:          todoID,
+			Start:           *request.Start,
+			End:             request.End,
+			DurationSeconds: int64(request.End.Sub(*request.Start).Seconds()),
+			Manual:          true,
+		}
+		if request.Note != nil {
+			entry.Note = *request.Note
+		}
+
+		if _, err := collection.InsertOne(context.TODO(), entry); err != nil {
+			log.Println("Error creating time entry:", err)
+			http.Error(w, "Failed to create time entry", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Time entry created successfully",
+			"entry":   entry,
+		})
+	}
+}
+
+func UpdateTimeEntry(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		entryID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid time entry ID", http.StatusBadRequest)
+			return
+		}
+
+		var entry models.TimeEntry
+		err = collection.FindOne(context.TODO(), bson.M{"_id": entryID, "user_id": user.ID}).Decode(&entry)
+		if err != nil {
+			http.Error(w, "Time entry not found", http.StatusNotFound)
+			return
+		}
+
+		var request entryRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if request.TodoID != nil {
+			if _, ok := todoAccess(w, userCollection, user, *request.TodoID, http.StatusBadRequest); !ok {
+				return
+			}
+			entry.TodoID = *request.TodoID
+		}
+		if request.Start != nil {
+			entry.Start = *request.Start
+		}
+		if request.Note != nil {
+			entry.Note = *request.Note
+		}
+
+		if entry.Running {
+			if request.End != nil || request.DurationMinutes > 0 {
+				http.Error(w, "Stop the timer before setting its end", http.StatusBadRequest)
+				return
+			}
+			if entry.Start.After(time.Now()) {
+				http.Error(w, "A running timer cannot start in the future", http.StatusBadRequest)
+				return
+			}
+		} else {
+			if request.End != nil {
+				entry.End = request.End
+			} else if request.DurationMinutes > 0 {
+				end := entry.Start.Add(time.Duration(request.DurationMinutes) * time.Minute)
+				entry.End = &end
+			}
+			if entry.End == nil || !entry.End.After(entry.Start) {
+				http.Error(w, "end must come after start", http.StatusBadRequest)
+				return
+			}
+			entry.DurationSeconds = int64(entry.End.Sub(entry.Start).Seconds())
+		}
+
+		_, err = collection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": entry.ID, "user_id": user.ID},
+			bson.M{"$set": bson.M{
+				"todo_id":          entry.TodoID,
+				"start":            entry.Start,
+				"end":              entry.End,
+				"duration_seconds": entry.DurationSeconds,
+				"note":             entry.Note,
+			}},
+		)
+		if err != nil {
+			log.Println("Error updating time entry:", err)
+			http.Error(w, "Failed to update time entry", http.StatusInternalServerError)
+			return
+		}
+
+		entry.DurationSeconds = duration(entry, time.Now())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Time entry updated successfully",
+			"entry":   entry,
+		})
+	}
+}
+
+func DeleteTimeEntry(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		entryID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid time entry ID", http.StatusBadRequest)
+			return
+		}
+
+		result, err := collection.DeleteOne(context.TODO(), bson.M{"_id": entryID, "user_id": user.ID})
+		if err != nil {
+			log.Println("Error deleting time entry:", err)
+			http.Error(w, "Failed to delete time entry", http.StatusInternalServerError)
+			return
+		}
+		if result.DeletedCount == 0 {
+			http.Error(w, "Time entry not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Time entry deleted successfully"})
+	}
+}
+
+func GetTimeTotals(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		values := r.URL.Query()
+
+		group := values.Get("group")
+		switch group {
+		case "":
+			group = GroupTodo
+		case GroupTodo, GroupList, GroupWeek:
+		default:
+			http.Error(w, "group must be todo, list or week", http.StatusBadRequest)
+			return
+		}
+
+		loc := utils.LoadLocation(user.TimeZone)
+		if tz := values.Get("tz"); tz != "" {
+			if loc, err = time.LoadLocation(tz); err != nil {
+				http.Error(w, "Invalid timezone", http.StatusBadRequest)
+				return
+			}
+		}
+
+		filter := bson.M{"user_id": user.ID}
+		start := bson.M{}
+		if value := values.Get("from"); value != "" {
+			from, err := time.ParseInLocation("2006-01-02", value, loc)
+			if err != nil {
+				http.Error(w, "from must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+			start["$gte"] = from
+		}
+		if value := values.Get("to"); value != "" {
+			to, err := time.ParseInLocation("2006-01-02", value, loc)
+			if err != nil {
+				http.Error(w, "to must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+			start["$lt"] = to.AddDate(0, 0, 1)
+		}
+		if len(start) > 0 {
+			filter["start"] = start
+		}
+
+		cursor, err := collection.Find(context.TODO(), filter, options.Find().SetSort(bson.M{"start": 1}))
+		if err != nil {
+			http.Error(w, "Failed to fetch time entries", http.StatusInternalServerError)
+			return
+		}
+
+		var entries []models.TimeEntry
+		if err := cursor.All(context.TODO(), &entries); err != nil {
+			http.Error(w, "Failed to fetch time entries", http.StatusInternalServerError)
+			return
+		}
+
+		user, err = withSharedTodos(context.TODO(), userCollection, user)
+		if err != nil {
+			log.Println("Error fetching shared todos:", err)
+			http.Error(w, "Failed to fetch time totals", http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		totals := Totals(entries, user, group, loc, now)
+
+		var total int64
+		for _, t := range totals {
+			total += t.DurationSeconds
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"group":         group,
+			"timezone":      loc.String(),
+			"total_seconds": total,
+			"totals":        totals,
+		})
+	}
+}
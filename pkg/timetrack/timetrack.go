@@ -0,0 +1,256 @@
+package timetrack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	GroupTodo = "todo"
+	GroupList = "list"
+	GroupWeek = "week"
+)
+
+var ErrTimerRunning = errors.New("a timer is already running")
+
+// EnsureIndexes creates the time entry indexes. The partial unique index on
+// running entries is what guarantees a single running timer per user, even
+// when two start requests race.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := config.TimeEntryCollection(db).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "todo_id", Value: 1}, {Key: "start", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "start", Value: -1}}},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"running": true}).
+				SetName("one_running_timer_per_user"),
+		},
+	})
+	return err
+}
+
+func findTodo(todos []models.Todo, id primitive.ObjectID) int {
+	for i, todo := range todos {
+		if todo.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// todoAccess finds a todo in the user's document or in a list shared with
+// them. Read access is enough, since the time entries belong to the user
+// logging them. It writes the error response itself.
+func todoAccess(w http.ResponseWriter, userCollection *mongo.Collection, user models.User, todoID primitive.ObjectID, notFound int) (models.Todo, bool) {
+	account, _, err := share.TodoAccount(context.TODO(), userCollection, user, todoID)
+	if errors.Is(err, share.ErrNotFound) {
+		http.Error(w, "Todo not found", notFound)
+		return models.Todo{}, false
+	}
+	if err != nil {
+		log.Println("Error resolving todo:", err)
+		http.Error(w, "Failed to resolve todo", http.StatusInternalServerError)
+		return models.Todo{}, false
+	}
+	return account.Todo[findTodo(account.Todo, todoID)], true
+}
+
+// withSharedTodos adds the todos and lists shared with the user to their
+// own, so time logged on them is labelled in the totals.
+func withSharedTodos(ctx context.Context, userCollection *mongo.Collection, user models.User) (models.User, error) {
+	accounts, err := share.SharedAccounts(ctx, userCollection, user)
+	if err != nil {
+		return user, err
+	}
+
+	user.Todo = append([]models.Todo{}, user.Todo...)
+	user.List = append([]models.List{}, user.List...)
+	for _, account := range accounts {
+		for _, list := range account.List {
+			for _, todo := range account.Todo {
+				if todo.ListID == list.ID {
+					user.Todo = append(user.Todo, todo)
+				}
+			}
+		}
+		user.List = append(user.List, account.List...)
+	}
+	return user, nil
+}
+
+func duration(entry models.TimeEntry, now time.Time) int64 {
+	if entry.Running {
+		if elapsed := int64(now.Sub(entry.Start).Seconds()); elapsed > 0 {
+			return elapsed
+		}
+		return 0
+	}
+	return entry.DurationSeconds
+}
+
+// withElapsed fills in the duration of running entries so clients can show a
+// live total without working it out themselves.
+func withElapsed(entries []models.TimeEntry, now time.Time) []models.TimeEntry {
+	for i := range entries {
+		entries[i].DurationSeconds = duration(entries[i], now)
+	}
+	return entries
+}
+
+func runningEntry(ctx context.Context, collection *mongo.Collection, userID string) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := collection.FindOne(ctx, bson.M{"user_id": userID, "running": true}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Stop ends the user's running timer, if any, and returns the finished entry.
+func Stop(ctx context.Context, collection *mongo.Collection, userID string, now time.Time) (*models.TimeEntry, error) {
+	entry, err := runningEntry(ctx, collection, userID)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	end := now
+	entry.End = &end
+	entry.DurationSeconds = duration(*entry, now)
+	entry.Running = false
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": entry.ID, "running": true},
+		bson.M{
+			"$set":   bson.M{"end": entry.End, "duration_seconds": entry.DurationSeconds},
+			"$unset": bson.M{"running": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		// Another request stopped it first.
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// Start stops whatever timer the user has running and starts a new one on
+// the given todo.
+func Start(ctx context.Context, collection *mongo.Collection, userID string, todoID primitive.ObjectID, note string, now time.Time) (*models.TimeEntry, *models.TimeEntry, error) {
+	stopped, err := Stop(ctx, collection, userID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := models.TimeEntry{
+		ID:      primitive.NewObjectID(),
+		UserID:  userID,
+		TodoID:  todoID,
+		Start:   now,
+		Note:    note,
+		Running: true,
+	}
+	if _, err := collection.InsertOne(ctx, entry); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, stopped, ErrTimerRunning
+		}
+		return nil, stopped, err
+	}
+	return &entry, stopped, nil
+}
+
+type Total struct {
+	Key             string `json:"key"`
+	Label           string `json:"label"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	Entries         int    `json:"entries"`
+	EstimateMinutes *int   `json:"estimate_minutes,omitempty"`
+}
+
+func weekKey(t time.Time, loc *time.Location) (string, string) {
+	local := t.In(loc)
+	year, week := local.ISOWeek()
+	offset := (int(local.Weekday()) + 6) % 7
+	monday := time.Date(local.Year(), local.Month(), local.Day()-offset, 0, 0, 0, 0, loc)
+	return fmt.Sprintf("%04d-W%02d", year, week), monday.Format("2006-01-02")
+}
+
+// Totals sums entries per todo, list or ISO week. Entries are attributed to
+// the week they started in, in loc. Time logged against todos that have
+// since been deleted is kept under an empty key so nothing billed goes
+// missing from the totals.
+func Totals(entries []models.TimeEntry, user models.User, group string, loc *time.Location, now time.Time) []Total {
+	listNames := make(map[primitive.ObjectID]string, len(user.List))
+	for _, list := range user.List {
+		listNames[list.ID] = list.Name
+	}
+
+	totals := make(map[string]*Total)
+	var order []string
+	for _, entry := range entries {
+		index := findTodo(user.Todo, entry.TodoID)
+
+		var total Total
+		switch group {
+		case GroupList:
+			switch {
+			case index == -1:
+				total = Total{Label: "Deleted todos"}
+			case user.Todo[index].ListID.IsZero():
+				total = Total{Key: primitive.NilObjectID.Hex(), Label: "No list"}
+			default:
+				listID := user.Todo[index].ListID
+				total = Total{Key: listID.Hex(), Label: listNames[listID]}
+			}
+		case GroupWeek:
+			total.Key, total.Label = weekKey(entry.Start, loc)
+		default:
+			if index == -1 {
+				total = Total{Key: entry.TodoID.Hex(), Label: "Deleted todo"}
+			} else {
+				estimate := user.Todo[index].Estimate
+				total = Total{Key: entry.TodoID.Hex(), Label: user.Todo[index].Name, EstimateMinutes: &estimate}
+			}
+		}
+
+		existing, ok := totals[total.Key]
+		if !ok {
+			existing = &total
+			totals[total.Key] = existing
+			order = append(order, total.Key)
+		}
+		existing.DurationSeconds += duration(entry, now)
+		existing.Entries++
+	}
+
+	result := make([]Total, 0, len(order))
+	for _, key := range order {
+		result = append(result, *totals[key])
+	}
+	if group == GroupWeek {
+		sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	} else {
+		sort.SliceStable(result, func(i, j int) bool { return result[i].DurationSeconds > result[j].DurationSeconds })
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// todoAccess finds a todo in the user's own document or in a list shared
+// with them and returns the account it lives in, which is where every write
+// to it has to go, along with its index there. Viewers only get through
+// when write is false. It writes the error response itself.
+func todoAccess(w http.ResponseWriter, userCollection *mongo.Collection, user models.User, todoID primitive.ObjectID, write bool) (models.User, int, bool) {
+	account, role, err := share.TodoAccount(context.TODO(), userCollection, user, todoID)
+	if errors.Is(err, share.ErrNotFound) {
+		http.Error(w, "Todo not found", http.StatusNotFound)
+		return models.User{}, -1, false
+	}
+	if err != nil {
+		log.Println("Error resolving todo:", err)
+		http.Error(w, "Failed to resolve todo", http.StatusInternalServerError)
+		return models.User{}, -1, false
+	}
+	if write && !share.CanEdit(role) {
+		http.Error(w, "You have read-only access to this list", http.StatusForbidden)
+		return models.User{}, -1, false
+	}
+	return account, findTodo(account.Todo, todoID), true
+}
+
+// listAccess is todoAccess for a list. notFound is the status to answer
+// with when the list is not the user's and not shared with them, since a
+// list named in a request body is a bad request rather than a missing page.
+func listAccess(w http.ResponseWriter, userCollection *mongo.Collection, user models.User, listID primitive.ObjectID, write bool, notFound int) (models.User, bool) {
+	account, role, err := share.ListAccount(context.TODO(), userCollection, user, listID)
+	if errors.Is(err, share.ErrNotFound) {
+		http.Error(w, "List not found", notFound)
+		return models.User{}, false
+	}
+	if err != nil {
+		log.Println("Error resolving list:", err)
+		http.Error(w, "Failed to resolve list", http.StatusInternalServerError)
+		return models.User{}, false
+	}
+	if write && !share.CanEdit(role) {
+		http.Error(w, "You have read-only access to this list", http.StatusForbidden)
+		return models.User{}, false
+	}
+	return account, true
+}
+
+// sharedPrerequisites keeps collaborators from making a todo in a shared
+// list depend on the owner's todos outside it, which they cannot see.
+func sharedPrerequisites(account models.User, user models.User, listID primitive.ObjectID, blockedBy []primitive.ObjectID) error {
+	if account.ID == user.ID {
+		return nil
+	}
+
+	inList := make(map[primitive.ObjectID]bool)
+	for _, member := range ListTodos(account.Todo, listID) {
+		inList[member.ID] = true
+	}
+	for _, prerequisite := range blockedBy {
+		if !inList[prerequisite] {
+			return fmt.Errorf("prerequisite %s not found", prerequisite.Hex())
+		}
+	}
+	return nil
+}
+
+// sharedTodos returns the todos of the lists other users share with the
+// user, decorated for the user. Blocked is worked out against all of the
+// owner's todos, including the ones the user cannot see.
+func sharedTodos(userCollection *mongo.Collection, user models.User, now time.Time) ([]models.Todo, error) {
+	accounts, err := share.SharedAccounts(context.TODO(), userCollection, user)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := make([]models.Todo, 0)
+	for _, account := range accounts {
+		DecorateTodos(account.Todo, user, now)
+		for _, list := range account.List {
+			for _, todo := range ListTodos(account.Todo, list.ID) {
+				todos = append(todos, *todo)
+			}
+		}
+	}
+	return todos, nil
+}
@@ -0,0 +1,414 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type boardColumn struct {
+	models.Column
+	Todos     []models.Todo `json:"todos"`
+	Open      int           `json:"open"`
+	AtLimit   bool          `json:"at_limit"`
+	OverLimit bool          `json:"over_limit"`
+}
+
+func findList(lists []models.List, id primitive.ObjectID) int {
+	for i, list := range lists {
+		if list.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// columnOf returns the column a todo shows up in. Todos that were never
+// placed, or whose column has since been deleted, sit in the first column.
+func columnOf(list models.List, todo models.Todo) primitive.ObjectID {
+	if len(list.Columns) == 0 {
+		return primitive.NilObjectID
+	}
+	for _, column := range list.Columns {
+		if column.ID == todo.ColumnID {
+			return column.ID
+		}
+	}
+	return list.Columns[0].ID
+}
+
+// columnLoad counts the open todos in a column, which is what WIP limits
+// apply to; finished work parked in a column does not take up capacity.
+func columnLoad(todos []models.Todo, list models.List, columnID primitive.ObjectID, except primitive.ObjectID) int {
+	load := 0
+	for _, todo := range todos {
+		if todo.ListID == list.ID && todo.ID != except && !todo.Done && columnOf(list, todo) == columnID {
+			load++
+		}
+	}
+	return load
+}
+
+func checkWIPLimit(todos []models.Todo, list models.List, column models.Column, except primitive.ObjectID) error {
+	if column.WIPLimit > 0 && columnLoad(todos, list, column.ID, except) >= column.WIPLimit {
+		return fmt.Errorf("column %q is at its WIP limit of %d", column.Name, column.WIPLimit)
+	}
+	return nil
+}
+
+// checkPlacement enforces the WIP limit of the column an open todo lands in
+// when it gets there from somewhere else: another column, another list, or
+// from being done. before is nil for a new todo.
+func checkPlacement(todos []models.Todo, lists []models.List, before *models.Todo, after models.Todo) error {
+	if after.Done {
+		return nil
+	}
+	listIndex := findList(lists, after.ListID)
+	if listIndex == -1 || len(lists[listIndex].Columns) == 0 {
+		return nil
+	}
+	list := lists[listIndex]
+
+	columnID := columnOf(list, after)
+	if before != nil && !before.Done && before.ListID == after.ListID && columnOf(list, *before) == columnID {
+		return nil
+	}
+	for _, column := range list.Columns {
+		if column.ID == columnID {
+			return checkWIPLimit(todos, list, column, after.ID)
+		}
+	}
+	return nil
+}
+
+// CheckColumnDeletion enforces the WIP limits of the columns that take in
+// the open todos of columnID when it is deleted from list: target, or the
+// first remaining column when target is nil.
+func CheckColumnDeletion(todos []models.Todo, list models.List, columnID primitive.ObjectID, target primitive.ObjectID) error {
+	remaining := list
+	remaining.Columns = nil
+	for _, column := range list.Columns {
+		if column.ID != columnID {
+			remaining.Columns = append(remaining.Columns, column)
+		}
+	}
+	if len(remaining.Columns) == 0 {
+		return nil
+	}
+
+	arrivals := make(map[primitive.ObjectID]int)
+	for _, todo := range ListTodos(todos, list.ID) {
+		if todo.Done || columnOf(list, *todo) != columnID {
+			continue
+		}
+		placed := *todo
+		if placed.ColumnID == columnID {
+			placed.ColumnID = target
+		}
+		arrivals[columnOf(remaining, placed)]++
+	}
+
+	for _, column := range remaining.Columns {
+		if column.WIPLimit > 0 && arrivals[column.ID] > 0 && columnLoad(todos, list, column.ID, primitive.NilObjectID)+arrivals[column.ID] > column.WIPLimit {
+			return fmt.Errorf("column %q would go over its WIP limit of %d", column.Name, column.WIPLimit)
+		}
+	}
+	return nil
+}
+
+func buildBoard(todos []models.Todo, list models.List) []boardColumn {
+	board := make([]boardColumn, len(list.Columns))
+	positions := make(map[primitive.ObjectID]int)
+	for i, column := range list.Columns {
+		board[i] = boardColumn{Column: column, Todos: make([]models.Todo, 0)}
+		positions[column.ID] = i
+	}
+
+	for _, todo := range ListTodos(todos, list.ID) {
+		column := &board[positions[columnOf(list, *todo)]]
+		column.Todos = append(column.Todos, *todo)
+		if !todo.Done {
+			column.Open++
+		}
+	}
+
+	for i := range board {
+		if limit := board[i].WIPLimit; limit > 0 {
+			board[i].AtLimit = board[i].Open >= limit
+			board[i].OverLimit = board[i].Open > limit
+		}
+	}
+	return board
+}
+
+func GetBoard(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid list ID", http.StatusBadRequest)
+			return
+		}
+
+		user, ok = listAccess(w, userCollection, user, listID, false, http.StatusNotFound)
+		if !ok {
+			return
+		}
+
+		index := findList(user.List, listID)
+		if index == -1 {
+			http.Error(w, "List not found", http.StatusNotFound)
+			return
+		}
+		list := user.List[index]
+
+		DecorateTodos(user.Todo, user, time.Now())
+
+		unassigned := make([]models.Todo, 0)
+		if len(list.Columns) == 0 {
+			for _, todo := range ListTodos(user.Todo, listID) {
+				unassigned = append(unassigned, *todo)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"list":       list,
+			"columns":    buildBoard(user.Todo, list),
+			"unassigned": unassigned,
+		})
+	}
+}
+
+func MoveToColumn(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+		todo := user.Todo[index]
+
+		var moveRequest struct {
+			ColumnID primitive.ObjectID  `json:"column_id"`
+			BeforeID *primitive.ObjectID `json:"before_id,omitempty"`
+			AfterID  *primitive.ObjectID `json:"after_id,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&moveRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		listIndex := findList(user.List, todo.ListID)
+		if listIndex == -1 || len(user.List[listIndex].Columns) == 0 {
+			http.Error(w, "The todo's list has no board columns", http.StatusBadRequest)
+			return
+		}
+		list := user.List[listIndex]
+
+		var target *models.Column
+		for i := range list.Columns {
+			if list.Columns[i].ID == moveRequest.ColumnID {
+				target = &list.Columns[i]
+			}
+		}
+		if target == nil {
+			http.Error(w, "Column not found in the todo's list", http.StatusBadRequest)
+			return
+		}
+
+		if !todo.Done && columnOf(list, todo) != target.ID {
+			if err := checkWIPLimit(user.Todo, list, *target, todoID); err != nil {
+				http.Error(w, "Cannot place todo: "+err.Error(), http.StatusConflict)
+				return
+			}
+		}
+
+		var members []*models.Todo
+		for _, member := range ListTodos(user.Todo, list.ID) {
+			if member.ID != todoID {
+				members = append(members, member)
+			}
+		}
+
+		for _, member := range members {
+			if member.Rank == "" {
+				if err := rebalanceRanks(collection, userCollection, user.ID, members); err != nil {
+					log.Println("Error rebalancing ranks:", err)
+					http.Error(w, "Failed to move todo", http.StatusInternalServerError)
+					return
+				}
+				break
+			}
+		}
+
+		// Ordering inside a column reuses the list rank: the todo is placed
+		// next to its neighbour in the column, wherever that sits in the list.
+		neighbours := func() (string, string, bool) {
+			var column []*models.Todo
+			for _, member := range members {
+				if columnOf(list, *member) == target.ID {
+					column = append(column, member)
+				}
+			}
+
+			position := len(column)
+			if moveRequest.AfterID != nil {
+				position = -1
+				for i, member := range column {
+					if member.ID == *moveRequest.AfterID {
+						position = i + 1
+					}
+				}
+			} else if moveRequest.BeforeID != nil {
+				position = -1
+				for i, member := range column {
+					if member.ID == *moveRequest.BeforeID {
+						position = i
+					}
+				}
+			}
+			if position == -1 {
+				return "", "", false
+			}
+
+			lo, hi := "", ""
+			if position > 0 {
+				lo = column[position-1].Rank
+			}
+			if position < len(column) {
+				hi = column[position].Rank
+			}
+			return lo, hi, true
+		}
+
+		lo, hi, found := neighbours()
+		if !found {
+			http.Error(w, "Neighbouring todo not found in the target column", http.StatusBadRequest)
+			return
+		}
+
+		rank := rankBetween(lo, hi)
+		if !validRank(rank, lo, hi) || len(rank) > maxRankLength {
+			if err := rebalanceRanks(collection, userCollection, user.ID, members); err != nil {
+				log.Println("Error rebalancing ranks:", err)
+				http.Error(w, "Failed to move todo", http.StatusInternalServerError)
+				return
+			}
+			lo, hi, _ = neighbours()
+			rank = rankBetween(lo, hi)
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, todoID)
+
+		_, err = collection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": todoID},
+			bson.M{"$set": bson.M{"column_id": target.ID, "rank": rank}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to move todo", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID, "todos._id": todoID},
+			bson.M{"$set": bson.M{"todos.$.column_id": target.ID, "todos.$.rank": rank}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update user's todo list", http.StatusInternalServerError)
+			return
+		}
+
+		recorder.Commit()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Todo moved successfully",
+			"id":        todoID.Hex(),
+			"column_id": target.ID,
+			"rank":      rank,
+		})
+	}
+}
@@ -0,0 +1,478 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/pkg/comment"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"github.com/userAdityaa/todo-backend/pkg/tag"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxBulkItems = 500
+
+type bulkRequest struct {
+	IDs       []primitive.ObjectID   `json:"ids"`
+	Filter    map[string]interface{} `json:"filter"`
+	Action    string                 `json:"action"`
+	ListID    *primitive.ObjectID    `json:"list_id,omitempty"`
+	Tag       string                 `json:"tag"`
+	DueDate   *time.Time             `json:"due_date,omitempty"`
+	ShiftDays int                    `json:"shift_days"`
+	Force     bool                   `json:"force"`
+}
+
+type bulkResult struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func filterValues(filter map[string]interface{}) url.Values {
+	values := url.Values{}
+	for key, value := range filter {
+		switch value := value.(type) {
+		case []interface{}:
+			for _, item := range value {
+				values.Add(key, fmt.Sprint(item))
+			}
+		case nil:
+		default:
+			values.Set(key, fmt.Sprint(value))
+		}
+	}
+	return values
+}
+
+// bulkAccount finds the document a todo of a bulk request lives in and
+// checks the user may change it. Documents are loaded once per request so
+// that the changes made for one todo are seen when handling the next.
+func bulkAccount(userCollection *mongo.Collection, user models.User, accounts map[string]*models.User, id primitive.ObjectID) (*models.User, error) {
+	if findTodo(user.Todo, id) != -1 {
+		return accounts[user.ID], nil
+	}
+
+	owner, role, err := share.TodoAccount(context.TODO(), userCollection, user, id)
+	if errors.Is(err, share.ErrNotFound) {
+		return nil, fmt.Errorf("todo not found")
+	}
+	if err != nil {
+		log.Println("Error resolving todo:", err)
+		return nil, fmt.Errorf("failed to resolve todo")
+	}
+	if !share.CanEdit(role) {
+		return nil, fmt.Errorf("read-only access to this list")
+	}
+
+	account, ok := accounts[owner.ID]
+	if !ok {
+		account = &owner
+		accounts[owner.ID] = account
+	}
+	if findTodo(account.Todo, id) == -1 {
+		return nil, fmt.Errorf("todo not found")
+	}
+	return account, nil
+}
+
+func bulkComplete(collection *mongo.Collection, userCollection *mongo.Collection, recorder *activity.Recorder, user *models.User, index int, force bool) error {
+	todo := user.Todo[index]
+	if todo.Done {
+		return fmt.Errorf("todo already completed")
+	}
+
+	withBlocked(user.Todo)
+	if user.Todo[index].Blocked && !force {
+		return fmt.Errorf("todo is blocked by unfinished prerequisites")
+	}
+
+	var next models.Todo
+	hasNext := false
+	if todo.Recurrence != nil && (todo.Recurrence.NextID.IsZero() || findTodo(user.Todo, todo.Recurrence.NextID) == -1) {
+		var err error
+		next, hasNext, err = nextOccurrence(todo, *user)
+		if err != nil {
+			return fmt.Errorf("failed to compute next occurrence")
+		}
+	}
+
+	completedAt := time.Now()
+	todoUpdate := bson.M{"done": true, "completed_at": completedAt}
+	userUpdate := bson.M{"todos.$.done": true, "todos.$.completed_at": completedAt}
+	if hasNext {
+		todoUpdate["recurrence.next_id"] = next.ID
+		userUpdate["todos.$.recurrence.next_id"] = next.ID
+	}
+
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": todo.ID},
+		bson.M{"$set": todoUpdate},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete todo")
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": user.ID, "todos._id": todo.ID},
+		bson.M{"$set": userUpdate},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user's todo list")
+	}
+
+	user.Todo[index].Done = true
+	user.Todo[index].CompletedAt = &completedAt
+
+	if hasNext {
+		user.Todo[index].Recurrence.NextID = next.ID
+		recorder.TrackIn(user.ID, activity.ItemTodo, next.ID)
+		if _, err := collection.InsertOne(context.TODO(), next); err != nil {
+			return fmt.Errorf("failed to create next occurrence")
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$push": bson.M{"todos": next}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update user with next occurrence")
+		}
+		user.Todo = append(user.Todo, next)
+	}
+	return nil
+}
+
+func bulkDelete(collection *mongo.Collection, userCollection *mongo.Collection, user *models.User, index int) error {
+	todoID := user.Todo[index].ID
+	if _, err := collection.DeleteOne(context.TODO(), bson.M{"_id": todoID}); err != nil {
+		return fmt.Errorf("error deleting todo")
+	}
+
+	_, err := userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": user.ID},
+		bson.M{"$pull": bson.M{"todos": bson.M{"_id": todoID}}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user todo list")
+	}
+
+	user.Todo = append(user.Todo[:index], user.Todo[index+1:]...)
+
+	if err := RemoveDependency(collection, userCollection, user.ID, todoID); err != nil {
+		log.Println("Failed to remove dependency on deleted todo:", err)
+	}
+	if err := comment.RemoveForTodos(context.TODO(), collection.Database(), todoID); err != nil {
+		log.Println("Failed to remove comments of deleted todo:", err)
+	}
+	for i := range user.Todo {
+		for j, id := range user.Todo[i].BlockedBy {
+			if id == todoID {
+				user.Todo[i].BlockedBy = append(user.Todo[i].BlockedBy[:j], user.Todo[i].BlockedBy[j+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func bulkMove(collection *mongo.Collection, userCollection *mongo.Collection, user *models.User, index int, listID primitive.ObjectID) error {
+	todo := &user.Todo[index]
+	if todo.ListID == listID {
+		return nil
+	}
+
+	moved := *todo
+	moved.ListID = listID
+	moved.ColumnID = primitive.NilObjectID
+	if err := checkPlacement(user.Todo, user.List, todo, moved); err != nil {
+		return fmt.Errorf("cannot place todo: %v", err)
+	}
+
+	rank, err := appendRank(collection, userCollection, user.ID, user.Todo, listID)
+	if err != nil {
+		return fmt.Errorf("failed to rebalance ranks")
+	}
+	_, err = collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": todo.ID},
+		bson.M{"$set": bson.M{"list_id": listID, "rank": rank, "column_id": primitive.NilObjectID}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to move todo")
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": user.ID, "todos._id": todo.ID},
+		bson.M{"$set": bson.M{"todos.$.list_id": listID, "todos.$.rank": rank, "todos.$.column_id": primitive.NilObjectID}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user's todo list")
+	}
+
+	todo.ListID = listID
+	todo.Rank = rank
+	todo.ColumnID = primitive.NilObjectID
+	return nil
+}
+
+func bulkAddTag(collection *mongo.Collection, userCollection *mongo.Collection, user *models.User, index int, name string) error {
+	todo := &user.Todo[index]
+	for _, existing := range todo.Tags {
+		if existing == name {
+			return nil
+		}
+	}
+
+	tags := append(append([]string{}, todo.Tags...), name)
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": todo.ID},
+		bson.M{"$set": bson.M{"tags": tags}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to tag todo")
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": user.ID, "todos._id": todo.ID},
+		bson.M{"$set": bson.M{"todos.$.tags": tags}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user's todo list")
+	}
+
+	todo.Tags = tags
+	return nil
+}
+
+func bulkReschedule(collection *mongo.Collection, userCollection *mongo.Collection, user *models.User, index int, dueDate *time.Time, shiftDays int) error {
+	todo := user.Todo[index]
+	if dueDate != nil {
+		due := *dueDate
+		todo.DueDate = &due
+	} else {
+		if todo.DueDate == nil {
+			return fmt.Errorf("todo has no due date to shift")
+		}
+		due := todo.DueDate.In(todoLocation(todo, *user)).AddDate(0, 0, shiftDays)
+		todo.DueDate = &due
+	}
+
+	if err := normalizeDueDate(&todo, *user); err != nil {
+		return err
+	}
+
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": todo.ID},
+		bson.M{"$set": bson.M{"due_date": todo.DueDate, "all_day": todo.AllDay}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule todo")
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": user.ID, "todos._id": todo.ID},
+		bson.M{"$set": bson.M{"todos.$.due_date": todo.DueDate, "todos.$.all_day": todo.AllDay}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user's todo list")
+	}
+
+	user.Todo[index] = todo
+	return nil
+}
+
+func BulkTodos(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var request bulkRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if (request.IDs == nil) == (request.Filter == nil) {
+			http.Error(w, "Provide either ids or a filter", http.StatusBadRequest)
+			return
+		}
+
+		var targetAccount string
+		switch request.Action {
+		case "complete", "delete":
+		case "move":
+			if request.ListID == nil {
+				http.Error(w, "list_id is required to move todos", http.StatusBadRequest)
+				return
+			}
+			target, ok := listAccess(w, userCollection, user, *request.ListID, true, http.StatusBadRequest)
+			if !ok {
+				return
+			}
+			targetAccount = target.ID
+		case "add_tag":
+			tags := tag.Normalize([]string{request.Tag})
+			if len(tags) == 0 {
+				http.Error(w, "tag is required to tag todos", http.StatusBadRequest)
+				return
+			}
+			request.Tag = tags[0]
+		case "reschedule":
+			if request.DueDate == nil && request.ShiftDays == 0 {
+				http.Error(w, "due_date or shift_days is required to reschedule todos", http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "Unknown action, expected complete, delete, move, add_tag or reschedule", http.StatusBadRequest)
+			return
+		}
+
+		ids := request.IDs
+		if request.Filter != nil {
+			query, err := parseTodoQuery(filterValues(request.Filter), user, time.Now())
+			if err != nil {
+				http.Error(w, "Invalid filter: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			// Like QueryTodos, a filter on a shared list runs against the
+			// todos of its owner.
+			candidates := user.Todo
+			if query.listID != nil {
+				account, ok := listAccess(w, userCollection, user, *query.listID, false, http.StatusBadRequest)
+				if !ok {
+					return
+				}
+				candidates = account.Todo
+			}
+
+			DecorateTodos(candidates, user, time.Now())
+			for _, todo := range candidates {
+				if query.matches(todo) {
+					ids = append(ids, todo.ID)
+				}
+			}
+		}
+
+		if len(ids) > maxBulkItems {
+			http.Error(w, fmt.Sprintf("At most %d todos can be changed at once", maxBulkItems), http.StatusBadRequest)
+			return
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, user.ID)
+		accounts := map[string]*models.User{user.ID: &user}
+		tagged := make(map[string]bool)
+		results := make([]bulkResult, 0, len(ids))
+		succeeded := 0
+		for _, id := range ids {
+			result := bulkResult{ID: id.Hex()}
+
+			account, err := bulkAccount(userCollection, user, accounts, id)
+			if err != nil {
+				result.Error = err.Error()
+				results = append(results, result)
+				continue
+			}
+			index := findTodo(account.Todo, id)
+			if request.Action == "move" && account.ID != targetAccount {
+				result.Error = "todos can only move between lists of the same owner"
+				results = append(results, result)
+				continue
+			}
+
+			recorder.TrackIn(account.ID, activity.ItemTodo, id)
+
+			switch request.Action {
+			case "complete":
+				err = bulkComplete(collection, userCollection, recorder, account, index, request.Force)
+			case "delete":
+				recorder.TrackIn(account.ID, activity.ItemTodo, dependentsOf(account.Todo, id)...)
+				err = bulkDelete(collection, userCollection, account, index)
+			case "move":
+				err = bulkMove(collection, userCollection, account, index, *request.ListID)
+			case "add_tag":
+				err = bulkAddTag(collection, userCollection, account, index, request.Tag)
+			case "reschedule":
+				err = bulkReschedule(collection, userCollection, account, index, request.DueDate, request.ShiftDays)
+			}
+
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.OK = true
+				succeeded++
+				tagged[account.ID] = true
+			}
+			results = append(results, result)
+		}
+
+		recorder.Commit()
+
+		if request.Action == "add_tag" {
+			for id := range tagged {
+				if err := tag.Register(userCollection, *accounts[id], []string{request.Tag}); err != nil {
+					log.Println("Error registering tags:", err)
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Bulk operation finished",
+			"action":    request.Action,
+			"succeeded": succeeded,
+			"failed":    len(results) - succeeded,
+			"results":   results,
+		})
+	}
+}
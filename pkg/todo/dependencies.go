@@ -0,0 +1,373 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type dependencyEdge struct {
+	From primitive.ObjectID `json:"from"`
+	To   primitive.ObjectID `json:"to"`
+}
+
+func withBlocked(todos []models.Todo) {
+	done := make(map[primitive.ObjectID]bool, len(todos))
+	for _, todo := range todos {
+		done[todo.ID] = todo.Done
+	}
+
+	for i := range todos {
+		todos[i].Blocked = false
+		for _, id := range todos[i].BlockedBy {
+			if isDone, exists := done[id]; exists && !isDone {
+				todos[i].Blocked = true
+				break
+			}
+		}
+	}
+}
+
+// errCycle marks the dependency errors caused by a cycle, which conflict
+// with the current state of the todos rather than being malformed input.
+var errCycle = errors.New("would create a cycle")
+
+// dependencyStatus answers malformed dependencies with 400 and reserves 409
+// for cycles.
+func dependencyStatus(err error) int {
+	if errors.Is(err, errCycle) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+func validateDependencies(todos []models.Todo, id primitive.ObjectID, blockedBy []primitive.ObjectID) error {
+	byID := make(map[primitive.ObjectID]models.Todo, len(todos))
+	for _, todo := range todos {
+		byID[todo.ID] = todo
+	}
+
+	seen := make(map[primitive.ObjectID]bool)
+	for _, prerequisite := range blockedBy {
+		if prerequisite == id {
+			return fmt.Errorf("a todo cannot block itself")
+		}
+		if _, exists := byID[prerequisite]; !exists {
+			return fmt.Errorf("prerequisite %s not found", prerequisite.Hex())
+		}
+		if seen[prerequisite] {
+			return fmt.Errorf("prerequisite %s listed twice", prerequisite.Hex())
+		}
+		seen[prerequisite] = true
+	}
+
+	visited := make(map[primitive.ObjectID]bool)
+	var reaches func(from primitive.ObjectID) bool
+	reaches = func(from primitive.ObjectID) bool {
+		if from == id {
+			return true
+		}
+		if visited[from] {
+			return false
+		}
+		visited[from] = true
+		for _, next := range byID[from].BlockedBy {
+			if reaches(next) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, prerequisite := range blockedBy {
+		if reaches(prerequisite) {
+			return fmt.Errorf("dependency on %s %w", prerequisite.Hex(), errCycle)
+		}
+	}
+	return nil
+}
+
+func unblockedBy(todos []models.Todo, completed primitive.ObjectID) []primitive.ObjectID {
+	var unblocked []primitive.ObjectID
+	withBlocked(todos)
+	for _, todo := range todos {
+		if todo.Done || todo.Blocked {
+			continue
+		}
+		for _, id := range todo.BlockedBy {
+			if id == completed {
+				unblocked = append(unblocked, todo.ID)
+				break
+			}
+		}
+	}
+	return unblocked
+}
+
+func dependentsOf(todos []models.Todo, prerequisite primitive.ObjectID) []primitive.ObjectID {
+	var dependents []primitive.ObjectID
+	for _, todo := range todos {
+		for _, id := range todo.BlockedBy {
+			if id == prerequisite {
+				dependents = append(dependents, todo.ID)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+// RemoveDependency drops deleted todos from the blocked_by of the todos
+// that depended on them, so no edge points at a todo that is gone.
+func RemoveDependency(collection *mongo.Collection, userCollection *mongo.Collection, userID string, prerequisites ...primitive.ObjectID) error {
+	if len(prerequisites) == 0 {
+		return nil
+	}
+
+	_, err := collection.UpdateMany(
+		context.TODO(),
+		bson.M{"blocked_by": bson.M{"$in": prerequisites}},
+		bson.M{"$pull": bson.M{"blocked_by": bson.M{"$in": prerequisites}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": userID},
+		bson.M{"$pull": bson.M{"todos.$[todo].blocked_by": bson.M{"$in": prerequisites}}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"todo.blocked_by": bson.M{"$in": prerequisites}}},
+		}),
+	)
+	return err
+}
+
+func SetDependencies(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		account, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		var dependencyRequest struct {
+			BlockedBy []primitive.ObjectID `json:"blocked_by"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&dependencyRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if dependencyRequest.BlockedBy == nil {
+			dependencyRequest.BlockedBy = []primitive.ObjectID{}
+		}
+
+		if err := sharedPrerequisites(account, user, account.Todo[index].ListID, dependencyRequest.BlockedBy); err != nil {
+			http.Error(w, "Invalid dependencies: "+err.Error(), dependencyStatus(err))
+			return
+		}
+		actorID := user.ID
+		user = account
+
+		if err := validateDependencies(user.Todo, todoID, dependencyRequest.BlockedBy); err != nil {
+			http.Error(w, "Invalid dependencies: "+err.Error(), dependencyStatus(err))
+			return
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, todoID)
+
+		_, err = collection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": todoID},
+			bson.M{"$set": bson.M{"blocked_by": dependencyRequest.BlockedBy}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update dependencies", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID, "todos._id": todoID},
+			bson.M{"$set": bson.M{"todos.$.blocked_by": dependencyRequest.BlockedBy}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update user's todo list", http.StatusInternalServerError)
+			return
+		}
+
+		recorder.Commit()
+
+		user.Todo[findTodo(user.Todo, todoID)].BlockedBy = dependencyRequest.BlockedBy
+		withBlocked(user.Todo)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":    "Dependencies updated successfully",
+			"id":         todoID.Hex(),
+			"blocked_by": dependencyRequest.BlockedBy,
+			"blocked":    user.Todo[findTodo(user.Todo, todoID)].Blocked,
+		})
+	}
+}
+
+func GetDependencyGraph(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		listID := primitive.NilObjectID
+		if id := chi.URLParam(r, "id"); id != "inbox" {
+			listID, err = primitive.ObjectIDFromHex(id)
+			if err != nil {
+				http.Error(w, "Invalid list ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		user, ok = listAccess(w, userCollection, user, listID, false, http.StatusNotFound)
+		if !ok {
+			return
+		}
+
+		DecorateTodos(user.Todo, user, time.Now())
+
+		members := ListTodos(user.Todo, listID)
+		inList := make(map[primitive.ObjectID]bool, len(members))
+		for _, todo := range members {
+			inList[todo.ID] = true
+		}
+
+		edges := make([]dependencyEdge, 0)
+		indegree := make(map[primitive.ObjectID]int, len(members))
+		dependents := make(map[primitive.ObjectID][]primitive.ObjectID)
+		for _, todo := range members {
+			for _, prerequisite := range todo.BlockedBy {
+				edges = append(edges, dependencyEdge{From: prerequisite, To: todo.ID})
+				if inList[prerequisite] {
+					indegree[todo.ID]++
+					dependents[prerequisite] = append(dependents[prerequisite], todo.ID)
+				}
+			}
+		}
+
+		var queue []primitive.ObjectID
+		for _, todo := range members {
+			if indegree[todo.ID] == 0 {
+				queue = append(queue, todo.ID)
+			}
+		}
+
+		order := make([]models.Todo, 0, len(members))
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			order = append(order, user.Todo[findTodo(user.Todo, id)])
+			for _, dependent := range dependents[id] {
+				indegree[dependent]--
+				if indegree[dependent] == 0 {
+					queue = append(queue, dependent)
+				}
+			}
+		}
+
+		if len(order) != len(members) {
+			http.Error(w, "Dependency cycle detected in list", http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(map[string]interface{}{
+			"list_id": listID,
+			"order":   order,
+			"edges":   edges,
+		})
+		if err != nil {
+			log.Println("Error encoding graph:", err)
+			http.Error(w, "Failed to fetch dependency graph", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+)
+
+var legacyDateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+var legacyDateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"02-01-2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Mon Jan 02 2006",
+}
+
+func todoLocation(todo models.Todo, user models.User) *time.Location {
+	if todo.TimeZone != "" {
+		return utils.LoadLocation(todo.TimeZone)
+	}
+	return utils.LoadLocation(user.TimeZone)
+}
+
+func normalizeDueDate(todo *models.Todo, user models.User) error {
+	if todo.TimeZone != "" {
+		if _, err := time.LoadLocation(todo.TimeZone); err != nil {
+			return fmt.Errorf("unknown timezone %q", todo.TimeZone)
+		}
+	}
+
+	if todo.DueDate == nil {
+		todo.AllDay = false
+		return nil
+	}
+
+	if todo.DueDate.IsZero() {
+		todo.DueDate = nil
+		todo.AllDay = false
+		return nil
+	}
+
+	if todo.AllDay {
+		due := utils.CalendarDay(*todo.DueDate, todoLocation(*todo, user))
+		todo.DueDate = &due
+	}
+	return nil
+}
+
+func withDueStatus(todos []models.Todo, user models.User, now time.Time) {
+	for i := range todos {
+		todos[i].Overdue = false
+		todos[i].DueToday = false
+		if todos[i].DueDate == nil || todos[i].Done {
+			continue
+		}
+
+		loc := todoLocation(todos[i], user)
+		due := *todos[i].DueDate
+		today := utils.StartOfDay(now, loc)
+
+		todos[i].DueToday = utils.SameDay(due, now, loc)
+		if todos[i].AllDay {
+			// Stored all-day dates already are midnight in loc.
+			todos[i].Overdue = due.Before(today)
+		} else {
+			todos[i].Overdue = due.Before(now)
+		}
+	}
+}
+
+func parseLegacyDueDate(value string, loc *time.Location) (time.Time, bool, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false, false
+	}
+
+	for _, layout := range legacyDateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, false, true
+		}
+	}
+
+	for _, layout := range legacyDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, true, true
+		}
+	}
+
+	return time.Time{}, false, false
+}
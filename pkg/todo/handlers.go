@@ -7,10 +7,15 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/pkg/comment"
+	"github.com/userAdityaa/todo-backend/pkg/reminder"
+	"github.com/userAdityaa/todo-backend/pkg/tag"
 	"github.com/userAdityaa/todo-backend/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -50,12 +55,20 @@ func GetAllTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 			return
 		}
 
-		if len(user.Todo) == 0 {
-			w.WriteHeader(http.StatusOK)
-			w.Header().Set("Content-Type", "application/json")
-			w.Write([]byte(`{"message": "No todos found for this user"}`))
-			return
+		query := r.URL.Query()
+		var priorities []int
+		for _, value := range query["priority"] {
+			for _, item := range strings.Split(value, ",") {
+				priority, err := parsePriority(item)
+				if err != nil {
+					http.Error(w, "Invalid priority: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				priorities = append(priorities, priority)
+			}
 		}
+		DecorateTodos(user.Todo, user, time.Now())
+		user.Todo = filterTodos(user.Todo, tag.Normalize(query["tag"]), priorities)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -70,15 +83,23 @@ func GetAllTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 
 func CreateTodo(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		quickAdd := r.URL.Query().Get("mode") == "quick"
+
 		var todo models.Todo
-		if err := json.NewDecoder(r.Body).Decode(&todo); err != nil {
+		var quickAddRequest struct {
+			Text string `json:"text"`
+		}
+
+		var target interface{} = &todo
+		if quickAdd {
+			target = &quickAddRequest
+		}
+		if err := json.NewDecoder(r.Body).Decode(target); err != nil {
 			log.Println(err)
 			http.Error(w, "Invalid request payload", http.StatusNotAcceptable)
 			return
 		}
 
-		todo.ID = primitive.NewObjectID()
-
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
 			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
@@ -110,11 +131,88 @@ func CreateTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 			return
 		}
 
+		var parsed quickAddResult
+		if quickAdd {
+			parsed = parseQuickAdd(quickAddRequest.Text, user, time.Now())
+			todo = parsed.todo()
+		}
+
+		todo.ID = primitive.NewObjectID()
+
 		if todo.Name == "" {
 			http.Error(w, "Name and DueDate are required field", http.StatusBadRequest)
 			return
 		}
 
+		if err := normalizeDueDate(&todo, user); err != nil {
+			http.Error(w, "Invalid due date: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !validPriority(todo.Priority) {
+			http.Error(w, "Priority must be between 0 and 3", http.StatusBadRequest)
+			return
+		}
+
+		if todo.Estimate < 0 {
+			http.Error(w, "Estimate cannot be negative", http.StatusBadRequest)
+			return
+		}
+
+		todo.Tags = tag.Normalize(todo.Tags)
+		todo.Attachments = nil
+		todo.CommentCount = 0
+
+		// A todo added to a shared list is stored with the list's owner.
+		account, ok := listAccess(w, userCollection, user, todo.ListID, true, http.StatusBadRequest)
+		if !ok {
+			return
+		}
+		if err := sharedPrerequisites(account, user, todo.ListID, todo.BlockedBy); err != nil {
+			http.Error(w, "Invalid dependencies: "+err.Error(), dependencyStatus(err))
+			return
+		}
+		actorID := user.ID
+		user = account
+
+		if err := validateDependencies(user.Todo, todo.ID, todo.BlockedBy); err != nil {
+			http.Error(w, "Invalid dependencies: "+err.Error(), dependencyStatus(err))
+			return
+		}
+
+		if !todo.ColumnID.IsZero() {
+			listIndex := findList(user.List, todo.ListID)
+			if listIndex == -1 || columnOf(user.List[listIndex], todo) != todo.ColumnID {
+				http.Error(w, "Column not found in the todo's list", http.StatusBadRequest)
+				return
+			}
+		}
+		if err := checkPlacement(user.Todo, user.List, nil, todo); err != nil {
+			http.Error(w, "Cannot place todo: "+err.Error(), http.StatusConflict)
+			return
+		}
+
+		if err := reminder.Normalize(todo.Reminders, todo.DueDate); err != nil {
+			http.Error(w, "Invalid reminders: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		todo.Rank, err = appendRank(collection, userCollection, user.ID, user.Todo, todo.ListID)
+		if err != nil {
+			log.Println("Error rebalancing ranks:", err)
+			http.Error(w, "Failed to create todo", http.StatusInternalServerError)
+			return
+		}
+		normalizeSubtasks(todo.Subtask)
+
+		if err := initRecurrence(&todo, user); err != nil {
+			http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, todo.ID)
+
 		_, err = collection.InsertOne(context.TODO(), todo)
 		if err != nil {
 			log.Fatal(err)
@@ -137,6 +235,11 @@ func CreateTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 			return
 		}
 
+		if err := tag.Register(userCollection, user, todo.Tags); err != nil {
+			log.Println("Error registering tags:", err)
+		}
+		recorder.Commit()
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
 		response := map[string]interface{}{
@@ -144,6 +247,10 @@ func CreateTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 			"id":       todo.ID.Hex(),
 			"name":     todo.Name,
 			"due_date": todo.DueDate,
+			"all_day":  todo.AllDay,
+		}
+		if quickAdd {
+			response["parsed"] = parsed
 		}
 
 		json.NewEncoder(w).Encode(response)
@@ -185,6 +292,20 @@ func DeleteTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 
 		id := chi.URLParam(r, "id")
 		filterID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, _, ok = todoAccess(w, userCollection, user, filterID, true)
+		if !ok {
+			return
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, filterID)
+		recorder.Track(activity.ItemTodo, dependentsOf(user.Todo, filterID)...)
 
 		_, err = collection.DeleteOne(context.TODO(), bson.M{"_id": filterID})
 		if err != nil {
@@ -194,7 +315,7 @@ func DeleteTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 
 		_, err = userCollection.UpdateOne(
 			context.TODO(),
-			bson.M{"email": email},
+			bson.M{"_id": user.ID},
 			bson.M{"$pull": bson.M{"todos": bson.M{"_id": filterID}}},
 		)
 
@@ -204,6 +325,14 @@ func DeleteTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 			return
 		}
 
+		if err := RemoveDependency(collection, userCollection, user.ID, filterID); err != nil {
+			log.Println("Failed to remove dependency on deleted todo:", err)
+		}
+		if err := comment.RemoveForTodos(context.TODO(), collection.Database(), filterID); err != nil {
+			log.Println("Failed to remove comments of deleted todo:", err)
+		}
+		recorder.Commit()
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Todo deleted successfully"))
@@ -261,16 +390,9 @@ func UpdateTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 			return
 		}
 
-		update := bson.M{
-			"$set": bson.M{
-				"name":        updatedTodo.Name,
-				"description": updatedTodo.Description,
-				"list":        updatedTodo.List,
-				"due_date":    updatedTodo.DueDate,
-				"sub_task":    updatedTodo.Subtask,
-				"user_id":     user.ID,
-				"updated_at":  time.Now(),
-			},
+		if err := normalizeDueDate(&updatedTodo, user); err != nil {
+			http.Error(w, "Invalid due date: "+err.Error(), http.StatusBadRequest)
+			return
 		}
 
 		filterID, err := primitive.ObjectIDFromHex(id)
@@ -279,12 +401,121 @@ func UpdateTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 			return
 		}
 
+		account, _, ok := todoAccess(w, userCollection, user, filterID, true)
+		if !ok {
+			return
+		}
+
+		// A todo can move between the lists of the account it lives in, but
+		// not out of a shared list into the collaborator's own lists.
+		target, ok := listAccess(w, userCollection, user, updatedTodo.ListID, true, http.StatusBadRequest)
+		if !ok {
+			return
+		}
+		if target.ID != account.ID {
+			http.Error(w, "Todos can only move between lists of the same owner", http.StatusBadRequest)
+			return
+		}
+		if err := sharedPrerequisites(account, user, updatedTodo.ListID, updatedTodo.BlockedBy); err != nil {
+			http.Error(w, "Invalid dependencies: "+err.Error(), dependencyStatus(err))
+			return
+		}
+		actorID := user.ID
+		user = account
+
+		if !validPriority(updatedTodo.Priority) {
+			http.Error(w, "Priority must be between 0 and 3", http.StatusBadRequest)
+			return
+		}
+
+		if updatedTodo.Estimate < 0 {
+			http.Error(w, "Estimate cannot be negative", http.StatusBadRequest)
+			return
+		}
+
+		fields := bson.M{
+			"name":             updatedTodo.Name,
+			"description":      updatedTodo.Description,
+			"list_id":          updatedTodo.ListID,
+			"due_date":         updatedTodo.DueDate,
+			"all_day":          updatedTodo.AllDay,
+			"timezone":         updatedTodo.TimeZone,
+			"priority":         updatedTodo.Priority,
+			"estimate_minutes": updatedTodo.Estimate,
+			"user_id":          user.ID,
+			"updated_at":       time.Now(),
+		}
+		if updatedTodo.Subtask != nil {
+			normalizeSubtasks(updatedTodo.Subtask)
+			fields["sub_task"] = updatedTodo.Subtask
+		}
+		if updatedTodo.Tags != nil {
+			updatedTodo.Tags = tag.Normalize(updatedTodo.Tags)
+			fields["tags"] = updatedTodo.Tags
+		}
+		if updatedTodo.Reminders != nil {
+			if err := reminder.Normalize(updatedTodo.Reminders, updatedTodo.DueDate); err != nil {
+				http.Error(w, "Invalid reminders: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			fields["reminders"] = updatedTodo.Reminders
+		}
+
+		update := bson.M{
+			"$set": fields,
+		}
+
 		fmt.Println("filter id: ", filterID)
 
+		scope := r.URL.Query().Get("scope")
+		if scope == "" {
+			scope = "future"
+		}
+		if scope != "this" && scope != "future" {
+			http.Error(w, "Scope must be 'this' or 'future'", http.StatusBadRequest)
+			return
+		}
+
+		existingIndex := findTodo(user.Todo, filterID)
+		var recurrence *models.Recurrence
+		if existingIndex != -1 && updatedTodo.BlockedBy != nil {
+			if err := validateDependencies(user.Todo, filterID, updatedTodo.BlockedBy); err != nil {
+				http.Error(w, "Invalid dependencies: "+err.Error(), dependencyStatus(err))
+				return
+			}
+			fields["blocked_by"] = updatedTodo.BlockedBy
+		}
+
+		if existingIndex != -1 {
+			recurrence, err = updatedRecurrence(user.Todo[existingIndex], updatedTodo, scope, user)
+			if err != nil {
+				http.Error(w, "Invalid recurrence: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			fields["recurrence"] = recurrence
+		}
+
+		// A board column only means something inside its own list.
+		listChanged := existingIndex != -1 && user.Todo[existingIndex].ListID != updatedTodo.ListID
+		if listChanged {
+			fields["column_id"] = primitive.NilObjectID
+
+			moved := user.Todo[existingIndex]
+			moved.ListID = updatedTodo.ListID
+			moved.ColumnID = primitive.NilObjectID
+			if err := checkPlacement(user.Todo, user.List, &user.Todo[existingIndex], moved); err != nil {
+				http.Error(w, "Cannot place todo: "+err.Error(), http.StatusConflict)
+				return
+			}
+		}
+
 		filter := bson.M{
 			"_id": filterID,
 		}
 
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, filterID)
+
 		result, err := collection.UpdateOne(context.TODO(), filter, update)
 		if err != nil {
 			http.Error(w, "Failed to update todo", http.StatusInternalServerError)
@@ -297,14 +528,37 @@ func UpdateTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 		}
 
 		userFilter := bson.M{"_id": user.ID, "todos._id": filterID}
+		userFields := bson.M{
+			"todos.$.name":             updatedTodo.Name,
+			"todos.$.description":      updatedTodo.Description,
+			"todos.$.list_id":          updatedTodo.ListID,
+			"todos.$.due_date":         updatedTodo.DueDate,
+			"todos.$.all_day":          updatedTodo.AllDay,
+			"todos.$.timezone":         updatedTodo.TimeZone,
+			"todos.$.priority":         updatedTodo.Priority,
+			"todos.$.estimate_minutes": updatedTodo.Estimate,
+		}
+		if updatedTodo.Subtask != nil {
+			userFields["todos.$.sub_task"] = updatedTodo.Subtask
+		}
+		if updatedTodo.Tags != nil {
+			userFields["todos.$.tags"] = updatedTodo.Tags
+		}
+		if updatedTodo.Reminders != nil {
+			userFields["todos.$.reminders"] = updatedTodo.Reminders
+		}
+		if existingIndex != -1 {
+			userFields["todos.$.recurrence"] = recurrence
+		}
+		if existingIndex != -1 && updatedTodo.BlockedBy != nil {
+			userFields["todos.$.blocked_by"] = updatedTodo.BlockedBy
+		}
+		if listChanged {
+			userFields["todos.$.column_id"] = primitive.NilObjectID
+		}
+
 		userUpdate := bson.M{
-			"$set": bson.M{
-				"todos.$.name":        updatedTodo.Name,
-				"todos.$.description": updatedTodo.Description,
-				"todos.$.list":        updatedTodo.List,
-				"todos.$.due_date":    updatedTodo.DueDate,
-				"todos.$.sub_task":    updatedTodo.Subtask,
-			},
+			"$set": userFields,
 		}
 
 		_, err = userCollection.UpdateOne(context.TODO(), userFilter, userUpdate)
@@ -313,6 +567,11 @@ func UpdateTodo(collection *mongo.Collection, userCollection *mongo.Collection)
 			return
 		}
 
+		if err := tag.Register(userCollection, user, updatedTodo.Tags); err != nil {
+			log.Println("Error registering tags:", err)
+		}
+		recorder.Commit()
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		response := map[string]interface{}{
@@ -0,0 +1,208 @@
+package todo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func MigrateSubtasks(collection *mongo.Collection, userCollection *mongo.Collection) error {
+	ctx := context.TODO()
+
+	cursor, err := userCollection.Find(ctx, bson.M{"todos.sub_task": bson.M{"$type": "string"}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user struct {
+			ID    string   `bson:"_id"`
+			Todos []bson.M `bson:"todos"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+
+		for _, todo := range user.Todos {
+			legacy, ok := todo["sub_task"].(bson.A)
+			if !ok || !isLegacySubtaskList(legacy) {
+				continue
+			}
+
+			subtasks := make([]models.Subtask, 0, len(legacy))
+			for position, value := range legacy {
+				title, _ := value.(string)
+				subtasks = append(subtasks, models.Subtask{
+					ID:       primitive.NewObjectID(),
+					Title:    title,
+					Position: position,
+				})
+			}
+			normalizeSubtasks(subtasks)
+			todo["sub_task"] = subtasks
+
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": todo["_id"]}, bson.M{"$set": bson.M{"sub_task": subtasks}}); err != nil {
+				return err
+			}
+		}
+
+		if _, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"todos": user.Todos}}); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func isLegacySubtaskList(values bson.A) bool {
+	for _, value := range values {
+		if _, ok := value.(string); !ok {
+			return false
+		}
+	}
+	return len(values) > 0
+}
+
+func MigrateDueDates(collection *mongo.Collection, userCollection *mongo.Collection) error {
+	ctx := context.TODO()
+
+	cursor, err := userCollection.Find(ctx, bson.M{"todos.due_date": bson.M{"$type": "string"}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user struct {
+			ID       string   `bson:"_id"`
+			TimeZone string   `bson:"timezone"`
+			Todos    []bson.M `bson:"todos"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+
+		loc := utils.LoadLocation(user.TimeZone)
+		for _, todo := range user.Todos {
+			legacy, ok := todo["due_date"].(string)
+			if !ok {
+				continue
+			}
+
+			fields := bson.M{"due_date": nil, "all_day": false}
+			if due, allDay, ok := parseLegacyDueDate(legacy, loc); ok {
+				fields["due_date"] = due
+				fields["all_day"] = allDay
+			} else if legacy != "" {
+				fields["legacy_due_date"] = legacy
+			}
+
+			for key, value := range fields {
+				todo[key] = value
+			}
+
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": todo["_id"]}, bson.M{"$set": fields}); err != nil {
+				return err
+			}
+		}
+
+		if _, err := userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"todos": user.Todos}}); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func MigrateListRefs(collection *mongo.Collection, listCollection *mongo.Collection, userCollection *mongo.Collection) error {
+	ctx := context.TODO()
+
+	cursor, err := userCollection.Find(ctx, bson.M{"todos.list": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user struct {
+			ID    string        `bson:"_id"`
+			Lists []models.List `bson:"list"`
+			Todos []bson.M      `bson:"todos"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+
+		for _, todo := range user.Todos {
+			name, ok := todo["list"].(string)
+			if _, exists := todo["list"]; !exists {
+				continue
+			}
+			delete(todo, "list")
+
+			listID := primitive.NilObjectID
+			if ok && strings.TrimSpace(name) != "" {
+				listID = resolveListName(user.Lists, name)
+				if listID.IsZero() {
+					list := models.List{ID: primitive.NewObjectID(), Name: strings.TrimSpace(name), Color: "#9ca3af"}
+					if _, err := listCollection.InsertOne(ctx, list); err != nil {
+						return err
+					}
+					user.Lists = append(user.Lists, list)
+					listID = list.ID
+				}
+			}
+			todo["list_id"] = listID
+
+			fields := bson.M{"list_id": listID}
+			if recurrence, ok := todo["recurrence"].(bson.M); ok {
+				if template, ok := recurrence["template"].(bson.M); ok {
+					delete(template, "list")
+					template["list_id"] = listID
+					fields["recurrence"] = recurrence
+				}
+			}
+
+			_, err := collection.UpdateOne(
+				ctx,
+				bson.M{"_id": todo["_id"]},
+				bson.M{"$set": fields, "$unset": bson.M{"list": ""}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := userCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": user.ID},
+			bson.M{"$set": bson.M{"todos": user.Todos, "list": user.Lists}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func resolveListName(lists []models.List, name string) primitive.ObjectID {
+	name = strings.TrimSpace(name)
+	for _, list := range lists {
+		if list.Name == name {
+			return list.ID
+		}
+	}
+	for _, list := range lists {
+		if strings.EqualFold(list.Name, name) {
+			return list.ID
+		}
+	}
+	return primitive.NilObjectID
+}
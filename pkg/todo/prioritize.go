@@ -0,0 +1,325 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/tag"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	QuadrantDo        = "do"
+	QuadrantSchedule  = "schedule"
+	QuadrantDelegate  = "delegate"
+	QuadrantEliminate = "eliminate"
+)
+
+const (
+	urgentDays         = 2
+	dueHorizonDays     = 14
+	ageHorizonDays     = 30
+	dueWeight          = 0.5
+	priorityWeight     = 0.35
+	ageWeight          = 0.15
+	defaultActionLimit = 10
+	maxActionLimit     = 100
+)
+
+var (
+	defaultUrgentTags    = []string{"urgent", "asap"}
+	defaultImportantTags = []string{"important"}
+)
+
+type scoreParts struct {
+	Due      float64 `json:"due"`
+	Priority float64 `json:"priority"`
+	Age      float64 `json:"age"`
+}
+
+type rankedTodo struct {
+	models.Todo
+	Urgent    bool       `json:"urgent"`
+	Important bool       `json:"important"`
+	Quadrant  string     `json:"quadrant"`
+	DaysLeft  *int       `json:"days_left"`
+	Score     float64    `json:"score"`
+	Parts     scoreParts `json:"score_parts"`
+}
+
+type prioritizer struct {
+	user          models.User
+	now           time.Time
+	urgentTags    []string
+	importantTags []string
+}
+
+// daysLeft counts calendar days until the todo is due in its own time zone,
+// so a todo due later today is 0 and one due yesterday is -1.
+func (p prioritizer) daysLeft(todo models.Todo) *int {
+	if todo.DueDate == nil {
+		return nil
+	}
+	loc := todoLocation(todo, p.user)
+	today := utils.StartOfDay(p.now, loc)
+	due := utils.StartOfDay(*todo.DueDate, loc)
+	days := int(math.Round(due.Sub(today).Hours() / 24))
+	return &days
+}
+
+func hasAnyTag(todoTags []string, wanted []string) bool {
+	for _, name := range wanted {
+		if hasAllTags(todoTags, []string{name}) {
+			return true
+		}
+	}
+	return false
+}
+
+// rank classifies a todo for the Eisenhower matrix and scores it for next
+// actions. A todo is urgent when it is overdue, due within urgentDays or
+// carries an urgent tag, and important when its priority is medium or high or
+// it carries an important tag. The score weighs how close the due date is
+// (linearly over dueHorizonDays, full marks once overdue), the priority, and
+// how long the todo has been waiting (capped at ageHorizonDays), scaled to
+// 0-100.
+func (p prioritizer) rank(todo models.Todo) rankedTodo {
+	ranked := rankedTodo{Todo: todo, DaysLeft: p.daysLeft(todo)}
+
+	ranked.Urgent = hasAnyTag(todo.Tags, p.urgentTags)
+	if ranked.DaysLeft != nil {
+		if todo.Overdue || *ranked.DaysLeft < urgentDays {
+			ranked.Urgent = true
+		}
+		switch {
+		case todo.Overdue:
+			ranked.Parts.Due = 1
+		case *ranked.DaysLeft < dueHorizonDays:
+			ranked.Parts.Due = 1 - float64(*ranked.DaysLeft)/dueHorizonDays
+		}
+	}
+
+	ranked.Important = todo.Priority >= models.PriorityMedium || hasAnyTag(todo.Tags, p.importantTags)
+	ranked.Parts.Priority = float64(todo.Priority) / models.PriorityHigh
+
+	age := p.now.Sub(todo.ID.Timestamp()).Hours() / 24
+	ranked.Parts.Age = math.Max(0, math.Min(age/ageHorizonDays, 1))
+
+	score := dueWeight*ranked.Parts.Due + priorityWeight*ranked.Parts.Priority + ageWeight*ranked.Parts.Age
+	ranked.Score = math.Round(score*1000) / 10
+
+	switch {
+	case ranked.Urgent && ranked.Important:
+		ranked.Quadrant = QuadrantDo
+	case ranked.Important:
+		ranked.Quadrant = QuadrantSchedule
+	case ranked.Urgent:
+		ranked.Quadrant = QuadrantDelegate
+	default:
+		ranked.Quadrant = QuadrantEliminate
+	}
+	return ranked
+}
+
+func sortRanked(todos []rankedTodo) {
+	sort.SliceStable(todos, func(i, j int) bool {
+		if todos[i].Score != todos[j].Score {
+			return todos[i].Score > todos[j].Score
+		}
+		return compareTodos(todos[i].Todo, todos[j].Todo, []sortKey{{field: "due_date"}, {field: "created"}}) < 0
+	})
+}
+
+func tagParam(r *http.Request, name string, fallback []string) []string {
+	value, ok := r.URL.Query()[name]
+	if !ok {
+		return fallback
+	}
+	return tag.Normalize(strings.Split(strings.Join(value, ","), ","))
+}
+
+// prioritizerFor reads the shared query parameters of the prioritisation
+// endpoints: ?tz, ?list_id and the tag overrides ?urgent_tags and
+// ?important_tags. It returns the open todos in scope, decorated.
+func prioritizerFor(w http.ResponseWriter, r *http.Request, user models.User) (prioritizer, []models.Todo, bool) {
+	loc, err := viewLocation(r, user)
+	if err != nil {
+		http.Error(w, "Invalid timezone", http.StatusBadRequest)
+		return prioritizer{}, nil, false
+	}
+	if r.URL.Query().Get("tz") != "" {
+		user.TimeZone = loc.String()
+	}
+
+	var listID *primitive.ObjectID
+	if value := r.URL.Query().Get("list_id"); value != "" {
+		id := primitive.NilObjectID
+		if value != "inbox" {
+			id, err = primitive.ObjectIDFromHex(value)
+			if err != nil || !ownsList(user, id) {
+				http.Error(w, "List not found", http.StatusBadRequest)
+				return prioritizer{}, nil, false
+			}
+		}
+		listID = &id
+	}
+
+	p := prioritizer{
+		user:          user,
+		now:           time.Now(),
+		urgentTags:    tagParam(r, "urgent_tags", defaultUrgentTags),
+		importantTags: tagParam(r, "important_tags", defaultImportantTags),
+	}
+
+	DecorateTodos(user.Todo, user, p.now)
+	todos := make([]models.Todo, 0, len(user.Todo))
+	for _, todo := range openTodos(user.Todo) {
+		if listID == nil || todo.ListID == *listID {
+			todos = append(todos, todo)
+		}
+	}
+	return p, todos, true
+}
+
+func GetMatrix(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		p, todos, ok := prioritizerFor(w, r, user)
+		if !ok {
+			return
+		}
+
+		quadrants := map[string][]rankedTodo{
+			QuadrantDo:        make([]rankedTodo, 0),
+			QuadrantSchedule:  make([]rankedTodo, 0),
+			QuadrantDelegate:  make([]rankedTodo, 0),
+			QuadrantEliminate: make([]rankedTodo, 0),
+		}
+		for _, todo := range todos {
+			ranked := p.rank(todo)
+			quadrants[ranked.Quadrant] = append(quadrants[ranked.Quadrant], ranked)
+		}
+		for _, quadrant := range quadrants {
+			sortRanked(quadrant)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(quadrants)
+		if err != nil {
+			log.Println("Error encoding matrix:", err)
+			http.Error(w, "Failed to fetch matrix", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetNextActions(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		limit := defaultActionLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxActionLimit {
+				http.Error(w, "Limit must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+		}
+
+		p, todos, ok := prioritizerFor(w, r, user)
+		if !ok {
+			return
+		}
+
+		actions := make([]rankedTodo, 0, len(todos))
+		for _, todo := range todos {
+			if !todo.Blocked {
+				actions = append(actions, p.rank(todo))
+			}
+		}
+		sortRanked(actions)
+		if len(actions) > limit {
+			actions = actions[:limit]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(actions)
+		if err != nil {
+			log.Println("Error encoding next actions:", err)
+			http.Error(w, "Failed to fetch next actions", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/userAdityaa/todo-backend/models"
+)
+
+var priorityNames = map[string]int{
+	"none":   models.PriorityNone,
+	"low":    models.PriorityLow,
+	"medium": models.PriorityMedium,
+	"high":   models.PriorityHigh,
+}
+
+func parsePriority(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if priority, ok := priorityNames[value]; ok {
+		return priority, nil
+	}
+
+	priority, err := strconv.Atoi(value)
+	if err != nil || !validPriority(priority) {
+		return 0, fmt.Errorf("unknown priority %q", value)
+	}
+	return priority, nil
+}
+
+func validPriority(priority int) bool {
+	return priority >= models.PriorityNone && priority <= models.PriorityHigh
+}
+
+func filterTodos(todos []models.Todo, tags []string, priorities []int) []models.Todo {
+	filtered := make([]models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if len(priorities) > 0 && !containsPriority(priorities, todo.Priority) {
+			continue
+		}
+		if !hasAllTags(todo.Tags, tags) {
+			continue
+		}
+		filtered = append(filtered, todo)
+	}
+	return filtered
+}
+
+func containsPriority(priorities []int, priority int) bool {
+	for _, p := range priorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAllTags(todoTags []string, wanted []string) bool {
+	for _, name := range wanted {
+		found := false
+		for _, tag := range todoTags {
+			if tag == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,391 @@
+package todo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/tag"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+type todoQuery struct {
+	listID     *primitive.ObjectID
+	status     string
+	tags       []string
+	priorities []int
+	dueBefore  *time.Time
+	dueAfter   *time.Time
+	text       string
+	sortSpec   string
+	sort       []sortKey
+	limit      int
+	cursor     *todoCursor
+	now        time.Time
+	user       models.User
+}
+
+type todoCursor struct {
+	Sort     string             `json:"s"`
+	ID       primitive.ObjectID `json:"id"`
+	Name     string             `json:"n"`
+	Priority int                `json:"p"`
+	Rank     string             `json:"r,omitempty"`
+	DueDate  *time.Time         `json:"d,omitempty"`
+}
+
+type todoPage struct {
+	Items      []models.Todo `json:"items"`
+	NextCursor *string       `json:"next_cursor"`
+}
+
+func parseDateParam(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("expected RFC 3339 timestamp or YYYY-MM-DD date, got %q", value)
+}
+
+func parseTodoQuery(values url.Values, user models.User, now time.Time) (todoQuery, error) {
+	query := todoQuery{now: now, user: user, limit: defaultPageSize}
+	loc := utils.LoadLocation(user.TimeZone)
+
+	if value := values.Get("list_id"); value != "" {
+		listID := primitive.NilObjectID
+		if value != "inbox" {
+			id, err := primitive.ObjectIDFromHex(value)
+			if err != nil {
+				return query, fmt.Errorf("list_id must be an ObjectID or inbox")
+			}
+			listID = id
+		}
+		query.listID = &listID
+	}
+
+	query.status = strings.ToLower(values.Get("status"))
+	switch query.status {
+	case "", "all", "open", "done", "overdue":
+	default:
+		return query, fmt.Errorf("status must be one of all, open, done, overdue")
+	}
+
+	query.tags = tag.Normalize(values["tag"])
+
+	for _, value := range values["priority"] {
+		for _, item := range strings.Split(value, ",") {
+			priority, err := parsePriority(item)
+			if err != nil {
+				return query, err
+			}
+			query.priorities = append(query.priorities, priority)
+		}
+	}
+
+	if value := values.Get("due_before"); value != "" {
+		t, err := parseDateParam(value, loc)
+		if err != nil {
+			return query, fmt.Errorf("due_before: %v", err)
+		}
+		query.dueBefore = &t
+	}
+
+	if value := values.Get("due_after"); value != "" {
+		t, err := parseDateParam(value, loc)
+		if err != nil {
+			return query, fmt.Errorf("due_after: %v", err)
+		}
+		query.dueAfter = &t
+	}
+
+	query.text = strings.ToLower(strings.TrimSpace(values.Get("q")))
+
+	query.sortSpec = values.Get("sort")
+	if query.sortSpec == "" {
+		query.sortSpec = "due_date"
+	}
+	for _, field := range strings.Split(query.sortSpec, ",") {
+		key := sortKey{field: strings.TrimSpace(field)}
+		if strings.HasPrefix(key.field, "-") {
+			key.desc = true
+			key.field = key.field[1:]
+		}
+		switch key.field {
+		case "due_date", "name", "priority", "created", "rank":
+		default:
+			return query, fmt.Errorf("unknown sort key %q", key.field)
+		}
+		query.sort = append(query.sort, key)
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return query, fmt.Errorf("limit must be a positive number")
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+		query.limit = limit
+	}
+
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil || cursor.Sort != query.sortSpec {
+			return query, fmt.Errorf("invalid cursor")
+		}
+		query.cursor = &cursor
+	}
+
+	return query, nil
+}
+
+func (q todoQuery) matches(todo models.Todo) bool {
+	if q.listID != nil && todo.ListID != *q.listID {
+		return false
+	}
+
+	switch q.status {
+	case "open":
+		if todo.Done {
+			return false
+		}
+	case "done":
+		if !todo.Done {
+			return false
+		}
+	case "overdue":
+		if !todo.Overdue {
+			return false
+		}
+	}
+
+	if len(q.priorities) > 0 && !containsPriority(q.priorities, todo.Priority) {
+		return false
+	}
+
+	if !hasAllTags(todo.Tags, q.tags) {
+		return false
+	}
+
+	if q.dueBefore != nil || q.dueAfter != nil {
+		if todo.DueDate == nil {
+			return false
+		}
+		if q.dueBefore != nil && !todo.DueDate.Before(*q.dueBefore) {
+			return false
+		}
+		if q.dueAfter != nil && todo.DueDate.Before(*q.dueAfter) {
+			return false
+		}
+	}
+
+	if q.text != "" && !todoContainsText(todo, q.text) {
+		return false
+	}
+
+	return true
+}
+
+func todoContainsText(todo models.Todo, text string) bool {
+	if strings.Contains(strings.ToLower(todo.Name), text) || strings.Contains(strings.ToLower(todo.Description), text) {
+		return true
+	}
+	for _, subtask := range todo.Subtask {
+		if strings.Contains(strings.ToLower(subtask.Title), text) {
+			return true
+		}
+	}
+	for _, name := range todo.Tags {
+		if strings.Contains(name, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareTodos(a models.Todo, b models.Todo, keys []sortKey) int {
+	for _, key := range keys {
+		if key.field == "due_date" && (a.DueDate == nil) != (b.DueDate == nil) {
+			if a.DueDate == nil {
+				return 1
+			}
+			return -1
+		}
+
+		c := 0
+		switch key.field {
+		case "due_date":
+			if a.DueDate != nil && b.DueDate != nil {
+				c = a.DueDate.Compare(*b.DueDate)
+			}
+		case "name":
+			c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		case "priority":
+			c = a.Priority - b.Priority
+		case "created":
+			c = a.ID.Timestamp().Compare(b.ID.Timestamp())
+		case "rank":
+			c = strings.Compare(a.Rank, b.Rank)
+		}
+
+		if key.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.ID.Hex(), b.ID.Hex())
+}
+
+func sortTodos(todos []models.Todo, keys []sortKey) {
+	sort.SliceStable(todos, func(i, j int) bool {
+		return compareTodos(todos[i], todos[j], keys) < 0
+	})
+}
+
+func encodeCursor(todo models.Todo, sortSpec string) string {
+	data, _ := json.Marshal(todoCursor{
+		Sort:     sortSpec,
+		ID:       todo.ID,
+		Name:     todo.Name,
+		Priority: todo.Priority,
+		Rank:     todo.Rank,
+		DueDate:  todo.DueDate,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (todoCursor, error) {
+	var cursor todoCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
+
+func (q todoQuery) run(todos []models.Todo) todoPage {
+	matched := make([]models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if q.matches(todo) {
+			matched = append(matched, todo)
+		}
+	}
+	sortTodos(matched, q.sort)
+
+	start := 0
+	if q.cursor != nil {
+		last := models.Todo{
+			ID:       q.cursor.ID,
+			Name:     q.cursor.Name,
+			Priority: q.cursor.Priority,
+			Rank:     q.cursor.Rank,
+			DueDate:  q.cursor.DueDate,
+		}
+		start = sort.Search(len(matched), func(i int) bool {
+			return compareTodos(last, matched[i], q.sort) < 0
+		})
+	}
+
+	end := start + q.limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	page := todoPage{Items: matched[start:end]}
+	if end < len(matched) {
+		next := encodeCursor(matched[end-1], q.sortSpec)
+		page.NextCursor = &next
+	}
+	return page
+}
+
+func QueryTodos(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		now := time.Now()
+		query, err := parseTodoQuery(r.URL.Query(), user, now)
+		if err != nil {
+			http.Error(w, "Invalid query: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Querying a shared list reads the todos from its owner's document.
+		todos := user.Todo
+		if query.listID != nil {
+			account, ok := listAccess(w, userCollection, user, *query.listID, false, http.StatusNotFound)
+			if !ok {
+				return
+			}
+			todos = account.Todo
+		}
+
+		DecorateTodos(todos, user, now)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(query.run(todos))
+		if err != nil {
+			log.Println("Error encoding todos:", err)
+			http.Error(w, "Failed to fetch todos", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/tag"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const weekdayPattern = `monday|mon|tuesday|tues|tue|wednesday|wed|thursday|thurs|thur|thu|friday|fri|saturday|sat|sunday|sun`
+
+const monthPattern = `january|jan|february|feb|march|mar|april|apr|may|june|jun|july|jul|august|aug|september|sept|sep|october|oct|november|nov|december|dec`
+
+var (
+	quickPriority      = regexp.MustCompile(`(?i)(?:^|\s)(?:!(high|medium|med|low|none|[0-3])|p([1-4]))(?:\s|$)`)
+	quickList          = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_\-/]+)`)
+	quickTag           = regexp.MustCompile(`(?:^|\s)@([\p{L}\p{N}_\-]+)`)
+	quickEveryWeekday  = regexp.MustCompile(`(?i)\b(?:every|each)\s+weekday\b`)
+	quickEveryDays     = regexp.MustCompile(`(?i)\b(?:every|each)\s+((?:` + weekdayPattern + `)(?:\s*(?:,|and|&)\s*(?:` + weekdayPattern + `))*)\b`)
+	quickEveryInterval = regexp.MustCompile(`(?i)\b(?:every|each)\s+(?:(other|\d+)\s+)?(day|week|month|year)s?(?:\s+on\s+the\s+(\d{1,2}|last)(?:st|nd|rd|th)?(?:\s+day)?)?\b`)
+	quickAdverb        = regexp.MustCompile(`(?i)\b(daily|weekly|monthly|yearly|annually)(?:\s+on\s+the\s+(\d{1,2}|last)(?:st|nd|rd|th)?(?:\s+day)?)?\b`)
+	quickISODate       = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	quickMonthDay      = regexp.MustCompile(`(?i)\b(?:on\s+)?(` + monthPattern + `)\.?\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?\b`)
+	quickDayMonth      = regexp.MustCompile(`(?i)\b(?:on\s+)?(\d{1,2})(?:st|nd|rd|th)?\s+(?:of\s+)?(` + monthPattern + `)(?:,?\s+(\d{4}))?\b`)
+	quickRelative      = regexp.MustCompile(`(?i)\b(today|tonight|tomorrow|tmrw|next\s+week|next\s+month)\b`)
+	quickInDuration    = regexp.MustCompile(`(?i)\bin\s+(\d+|a|an)\s+(day|week|month)s?\b`)
+	quickWeekday       = regexp.MustCompile(`(?i)\b(?:on\s+)?(next\s+)?(` + weekdayPattern + `)\b`)
+	quickDayOfMonth    = regexp.MustCompile(`(?i)\bon\s+the\s+(\d{1,2})(?:st|nd|rd|th)\b`)
+	quickClock         = regexp.MustCompile(`(?i)\b(?:at\s+)?(\d{1,2})(?::(\d{2}))?\s*(am|pm)\b`)
+	quickClock24       = regexp.MustCompile(`(?i)\b(?:at\s+)?([01]?\d|2[0-3]):([0-5]\d)\b`)
+	quickNoon          = regexp.MustCompile(`(?i)\b(?:at\s+)?(noon|midnight)\b`)
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+type quickAddResult struct {
+	Name       string             `json:"name"`
+	DueDate    *time.Time         `json:"due_date"`
+	AllDay     bool               `json:"all_day"`
+	Recurrence string             `json:"recurrence,omitempty"`
+	ListID     primitive.ObjectID `json:"list_id"`
+	ListName   string             `json:"list_name,omitempty"`
+	Tags       []string           `json:"tags"`
+	Priority   int                `json:"priority"`
+}
+
+func (q quickAddResult) todo() models.Todo {
+	todo := models.Todo{
+		Name:     q.Name,
+		DueDate:  q.DueDate,
+		AllDay:   q.AllDay,
+		ListID:   q.ListID,
+		Tags:     q.Tags,
+		Priority: q.Priority,
+	}
+	if q.Recurrence != "" {
+		todo.Recurrence = &models.Recurrence{Rule: q.Recurrence}
+	}
+	return todo
+}
+
+func parseWeekday(name string) time.Weekday {
+	return weekdayNames[strings.ToLower(name)[:3]]
+}
+
+func parseMonth(name string) time.Month {
+	prefix := strings.ToLower(name)[:3]
+	for month := time.January; month <= time.December; month++ {
+		if strings.ToLower(month.String())[:3] == prefix {
+			return month
+		}
+	}
+	return time.January
+}
+
+func ordinalMonthDay(value string) int {
+	if strings.EqualFold(value, "last") {
+		return -1
+	}
+	day, _ := strconv.Atoi(value)
+	return day
+}
+
+func parseQuickAdd(text string, user models.User, now time.Time) quickAddResult {
+	loc := utils.LoadLocation(user.TimeZone)
+	now = now.In(loc)
+	today := utils.StartOfDay(now, loc)
+	result := quickAddResult{Tags: []string{}}
+
+	remove := func(re *regexp.Regexp) []string {
+		match := re.FindStringSubmatch(text)
+		if match != nil {
+			text = strings.Replace(text, match[0], " ", 1)
+		}
+		return match
+	}
+
+	for match := remove(quickPriority); match != nil; match = remove(quickPriority) {
+		value := strings.ToLower(match[1])
+		switch {
+		case value == "med":
+			value = "medium"
+		case match[2] != "":
+			level, _ := strconv.Atoi(match[2])
+			value = strconv.Itoa(models.PriorityHigh + 1 - level)
+		}
+		if priority, err := parsePriority(value); err == nil {
+			result.Priority = priority
+		}
+	}
+
+	for match := remove(quickList); match != nil; match = remove(quickList) {
+		if listID := resolveListName(user.List, match[1]); !listID.IsZero() && result.ListID.IsZero() {
+			result.ListID = listID
+			for _, list := range user.List {
+				if list.ID == listID {
+					result.ListName = list.Name
+				}
+			}
+		} else {
+			result.Tags = append(result.Tags, match[1])
+		}
+	}
+
+	for match := remove(quickTag); match != nil; match = remove(quickTag) {
+		result.Tags = append(result.Tags, match[1])
+	}
+	result.Tags = tag.Normalize(result.Tags)
+
+	var rule string
+	if match := remove(quickEveryWeekday); match != nil {
+		rule = "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"
+	} else if match := remove(quickEveryDays); match != nil {
+		var codes []string
+		for _, name := range regexp.MustCompile(`(?i)`+weekdayPattern).FindAllString(match[1], -1) {
+			codes = append(codes, weekdayCodes[parseWeekday(name)])
+		}
+		rule = "FREQ=WEEKLY;BYDAY=" + strings.Join(codes, ",")
+	} else if match := remove(quickEveryInterval); match != nil {
+		rule = "FREQ=" + map[string]string{"day": "DAILY", "week": "WEEKLY", "month": "MONTHLY", "year": "YEARLY"}[strings.ToLower(match[2])]
+		if match[1] == "other" {
+			rule += ";INTERVAL=2"
+		} else if interval, err := strconv.Atoi(match[1]); err == nil && interval > 1 {
+			rule += fmt.Sprintf(";INTERVAL=%d", interval)
+		}
+		if match[3] != "" && strings.EqualFold(match[2], "month") {
+			rule += fmt.Sprintf(";BYMONTHDAY=%d", ordinalMonthDay(match[3]))
+		}
+	} else if match := remove(quickAdverb); match != nil {
+		rule = "FREQ=" + map[string]string{"daily": "DAILY", "weekly": "WEEKLY", "monthly": "MONTHLY", "yearly": "YEARLY", "annually": "YEARLY"}[strings.ToLower(match[1])]
+		if match[2] != "" && strings.EqualFold(match[1], "monthly") {
+			rule += fmt.Sprintf(";BYMONTHDAY=%d", ordinalMonthDay(match[2]))
+		}
+	}
+
+	var date *time.Time
+	setDate := func(t time.Time) {
+		if date == nil {
+			date = &t
+		}
+	}
+
+	if match := remove(quickISODate); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		day, _ := strconv.Atoi(match[3])
+		setDate(time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc))
+	}
+
+	for _, re := range []*regexp.Regexp{quickMonthDay, quickDayMonth} {
+		match := remove(re)
+		if match == nil {
+			continue
+		}
+		monthName, dayValue := match[1], match[2]
+		if re == quickDayMonth {
+			monthName, dayValue = match[2], match[1]
+		}
+		day, _ := strconv.Atoi(dayValue)
+		candidate := time.Date(today.Year(), parseMonth(monthName), day, 0, 0, 0, 0, loc)
+		if match[3] != "" {
+			year, _ := strconv.Atoi(match[3])
+			candidate = time.Date(year, parseMonth(monthName), day, 0, 0, 0, 0, loc)
+		} else if candidate.Before(today) {
+			candidate = candidate.AddDate(1, 0, 0)
+		}
+		setDate(candidate)
+	}
+
+	if match := remove(quickRelative); match != nil {
+		switch strings.Join(strings.Fields(strings.ToLower(match[1])), " ") {
+		case "today", "tonight":
+			setDate(today)
+		case "tomorrow", "tmrw":
+			setDate(today.AddDate(0, 0, 1))
+		case "next week":
+			setDate(today.AddDate(0, 0, 7-(int(today.Weekday())+6)%7))
+		case "next month":
+			setDate(time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, loc))
+		}
+	}
+
+	if match := remove(quickInDuration); match != nil {
+		amount := 1
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			amount = n
+		}
+		switch strings.ToLower(match[2]) {
+		case "day":
+			setDate(today.AddDate(0, 0, amount))
+		case "week":
+			setDate(today.AddDate(0, 0, 7*amount))
+		case "month":
+			setDate(today.AddDate(0, amount, 0))
+		}
+	}
+
+	if date == nil && rule == "" {
+		if match := remove(quickWeekday); match != nil {
+			days := (int(parseWeekday(match[2])) - int(today.Weekday()) + 7) % 7
+			if days == 0 || match[1] != "" {
+				days += 7
+			}
+			setDate(today.AddDate(0, 0, days))
+		}
+	}
+
+	if match := remove(quickDayOfMonth); match != nil {
+		day, _ := strconv.Atoi(match[1])
+		if rule != "" && strings.HasPrefix(rule, "FREQ=MONTHLY") && !strings.Contains(rule, "BYMONTHDAY") {
+			rule += fmt.Sprintf(";BYMONTHDAY=%d", day)
+		} else {
+			candidate := time.Date(today.Year(), today.Month(), day, 0, 0, 0, 0, loc)
+			if candidate.Before(today) {
+				candidate = time.Date(today.Year(), today.Month()+1, day, 0, 0, 0, 0, loc)
+			}
+			setDate(candidate)
+		}
+	}
+
+	hour, minute, hasTime := 0, 0, false
+	if match := remove(quickClock); match != nil {
+		hour, _ = strconv.Atoi(match[1])
+		minute, _ = strconv.Atoi(match[2])
+		hour %= 12
+		if strings.EqualFold(match[3], "pm") {
+			hour += 12
+		}
+		hasTime = hour < 24 && minute < 60
+	} else if match := remove(quickClock24); match != nil {
+		hour, _ = strconv.Atoi(match[1])
+		minute, _ = strconv.Atoi(match[2])
+		hasTime = true
+	} else if match := remove(quickNoon); match != nil {
+		hasTime = true
+		if strings.EqualFold(match[1], "noon") {
+			hour = 12
+		}
+	}
+
+	if rule != "" {
+		result.Recurrence = rule
+		if date == nil {
+			if parsed, err := parseRRule(rule, loc); err == nil {
+				first := firstMatchingDay(parsed, today)
+				date = &first
+			}
+		}
+	}
+
+	if date == nil && hasTime {
+		candidate := time.Date(today.Year(), today.Month(), today.Day(), hour, minute, 0, 0, loc)
+		if candidate.Before(now) {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+		date = &candidate
+	} else if date != nil && hasTime {
+		withTime := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
+		date = &withTime
+	}
+
+	if date != nil {
+		result.DueDate = date
+		result.AllDay = !hasTime
+	}
+
+	result.Name = strings.Join(strings.Fields(text), " ")
+	return result
+}
+
+func firstMatchingDay(rule rrule, from time.Time) time.Time {
+	for i := 0; i < 366; i++ {
+		day := from.AddDate(0, 0, i)
+		if !rule.matchesMonth(day) {
+			continue
+		}
+
+		switch rule.freq {
+		case "WEEKLY", "DAILY":
+			if rule.matchesWeekday(day) {
+				return day
+			}
+		case "MONTHLY", "YEARLY":
+			if len(rule.byMonthDay) == 0 && len(rule.byDay) == 0 {
+				return day
+			}
+			for _, candidate := range rule.monthCandidates(day.Year(), day.Month(), day.Day(), func(year int, month time.Month, d int) time.Time {
+				return time.Date(year, month, d, 0, 0, 0, 0, from.Location())
+			}) {
+				if candidate.Equal(day) {
+					return day
+				}
+			}
+		}
+	}
+	return from
+}
+
+func ParseQuickAdd(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var parseRequest struct {
+			Text string `json:"text"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&parseRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if strings.TrimSpace(parseRequest.Text) == "" {
+			http.Error(w, "Text is a required field", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(parseQuickAdd(parseRequest.Text, user, time.Now()))
+	}
+}
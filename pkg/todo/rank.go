@@ -0,0 +1,358 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	rankAlphabet  = "0123456789abcdefghijklmnopqrstuvwxyz"
+	rankBase      = len(rankAlphabet)
+	maxRankLength = 12
+)
+
+func rankDigit(c byte) int {
+	return strings.IndexByte(rankAlphabet, c)
+}
+
+// rankBetween returns a key that sorts strictly between lo and hi, where an
+// empty lo or hi stands for the start or end of the list.
+func rankBetween(lo string, hi string) string {
+	var key []byte
+	bounded := hi != ""
+
+	for i := 0; ; i++ {
+		l := 0
+		if i < len(lo) {
+			l = rankDigit(lo[i])
+		}
+		h := rankBase
+		if bounded {
+			if i >= len(hi) {
+				return ""
+			}
+			h = rankDigit(hi[i])
+		}
+
+		if h-l > 1 {
+			return string(append(key, rankAlphabet[(l+h)/2]))
+		}
+		if h < l {
+			return ""
+		}
+
+		key = append(key, rankAlphabet[l])
+		if h-l == 1 {
+			bounded = false
+		}
+	}
+}
+
+func validRank(key string, lo string, hi string) bool {
+	return key != "" && key > lo && (hi == "" || key < hi)
+}
+
+func evenRanks(n int) []string {
+	width := 1
+	space := rankBase
+	for space <= n+1 {
+		width++
+		space *= rankBase
+	}
+
+	step := space / (n + 1)
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := (i + 1) * step
+		key := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			key[j] = rankAlphabet[value%rankBase]
+			value /= rankBase
+		}
+		ranks[i] = string(key)
+	}
+	return ranks
+}
+
+func ListTodos(todos []models.Todo, listID primitive.ObjectID) []*models.Todo {
+	var members []*models.Todo
+	for i := range todos {
+		if todos[i].ListID == listID {
+			members = append(members, &todos[i])
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].Rank != members[j].Rank {
+			if members[i].Rank == "" || members[j].Rank == "" {
+				return members[j].Rank == ""
+			}
+			return members[i].Rank < members[j].Rank
+		}
+		return members[i].ID.Timestamp().Before(members[j].ID.Timestamp())
+	})
+	return members
+}
+
+func lastRank(todos []models.Todo, listID primitive.ObjectID) string {
+	last := ""
+	for _, todo := range todos {
+		if todo.ListID == listID && todo.Rank > last {
+			last = todo.Rank
+		}
+	}
+	return last
+}
+
+func rebalanceRanks(collection *mongo.Collection, userCollection *mongo.Collection, userID string, members []*models.Todo) error {
+	ranks := evenRanks(len(members))
+	for i, todo := range members {
+		todo.Rank = ranks[i]
+
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": todo.ID}, bson.M{"$set": bson.M{"rank": todo.Rank}}); err != nil {
+			return err
+		}
+
+		_, err := userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": userID, "todos._id": todo.ID},
+			bson.M{"$set": bson.M{"todos.$.rank": todo.Rank}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendRank returns the key for a todo added at the end of a list. Every
+// append makes the key a little longer, so the list is rebalanced once it
+// passes maxRankLength, the same as MoveTodo does.
+func appendRank(collection *mongo.Collection, userCollection *mongo.Collection, userID string, todos []models.Todo, listID primitive.ObjectID) (string, error) {
+	rank := rankBetween(lastRank(todos, listID), "")
+	if len(rank) <= maxRankLength {
+		return rank, nil
+	}
+
+	if err := rebalanceRanks(collection, userCollection, userID, ListTodos(todos, listID)); err != nil {
+		return "", err
+	}
+	return rankBetween(lastRank(todos, listID), ""), nil
+}
+
+// appendRanks returns the keys for n todos added at the end of a list in one
+// go. They are spread evenly behind a single new key instead of each one
+// being appended after the previous.
+func appendRanks(collection *mongo.Collection, userCollection *mongo.Collection, userID string, todos []models.Todo, listID primitive.ObjectID, n int) ([]string, error) {
+	ranks := evenRanks(n)
+	if lastRank(todos, listID) == "" {
+		return ranks, nil
+	}
+
+	prefix, err := appendRank(collection, userCollection, userID, todos, listID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ranks {
+		ranks[i] = prefix + ranks[i]
+	}
+	return ranks, nil
+}
+
+func MoveTodo(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		account, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		var moveRequest struct {
+			ListID   *primitive.ObjectID `json:"list_id,omitempty"`
+			BeforeID *primitive.ObjectID `json:"before_id,omitempty"`
+			AfterID  *primitive.ObjectID `json:"after_id,omitempty"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&moveRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		listID := account.Todo[index].ListID
+		if moveRequest.ListID != nil {
+			listID = *moveRequest.ListID
+		}
+
+		target, ok := listAccess(w, userCollection, user, listID, true, http.StatusBadRequest)
+		if !ok {
+			return
+		}
+		if target.ID != account.ID {
+			http.Error(w, "Todos can only move between lists of the same owner", http.StatusBadRequest)
+			return
+		}
+		actorID := user.ID
+		user = account
+
+		var members []*models.Todo
+		for _, member := range ListTodos(user.Todo, listID) {
+			if member.ID != todoID {
+				members = append(members, member)
+			}
+		}
+
+		for _, member := range members {
+			if member.Rank == "" {
+				if err := rebalanceRanks(collection, userCollection, user.ID, members); err != nil {
+					log.Println("Error rebalancing ranks:", err)
+					http.Error(w, "Failed to move todo", http.StatusInternalServerError)
+					return
+				}
+				break
+			}
+		}
+
+		position := len(members)
+		if moveRequest.AfterID != nil {
+			position = -1
+			for i, member := range members {
+				if member.ID == *moveRequest.AfterID {
+					position = i + 1
+				}
+			}
+		} else if moveRequest.BeforeID != nil {
+			position = -1
+			for i, member := range members {
+				if member.ID == *moveRequest.BeforeID {
+					position = i
+				}
+			}
+		}
+
+		if position == -1 {
+			http.Error(w, "Neighbouring todo not found in the target list", http.StatusBadRequest)
+			return
+		}
+
+		lo, hi := "", ""
+		if position > 0 {
+			lo = members[position-1].Rank
+		}
+		if position < len(members) {
+			hi = members[position].Rank
+		}
+
+		rank := rankBetween(lo, hi)
+		if !validRank(rank, lo, hi) || len(rank) > maxRankLength {
+			if err := rebalanceRanks(collection, userCollection, user.ID, members); err != nil {
+				log.Println("Error rebalancing ranks:", err)
+				http.Error(w, "Failed to move todo", http.StatusInternalServerError)
+				return
+			}
+
+			lo, hi = "", ""
+			if position > 0 {
+				lo = members[position-1].Rank
+			}
+			if position < len(members) {
+				hi = members[position].Rank
+			}
+			rank = rankBetween(lo, hi)
+		}
+
+		fields := bson.M{"list_id": listID, "rank": rank}
+		userFields := bson.M{"todos.$.list_id": listID, "todos.$.rank": rank}
+		if listID != user.Todo[index].ListID {
+			fields["column_id"] = primitive.NilObjectID
+			userFields["todos.$.column_id"] = primitive.NilObjectID
+
+			moved := user.Todo[index]
+			moved.ListID = listID
+			moved.ColumnID = primitive.NilObjectID
+			if err := checkPlacement(user.Todo, user.List, &user.Todo[index], moved); err != nil {
+				http.Error(w, "Cannot place todo: "+err.Error(), http.StatusConflict)
+				return
+			}
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, todoID)
+
+		_, err = collection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": todoID},
+			bson.M{"$set": fields},
+		)
+		if err != nil {
+			http.Error(w, "Failed to move todo", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID, "todos._id": todoID},
+			bson.M{"$set": userFields},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update user's todo list", http.StatusInternalServerError)
+			return
+		}
+
+		recorder.Commit()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Todo moved successfully",
+			"id":      todoID.Hex(),
+			"list_id": listID,
+			"rank":    rank,
+		})
+	}
+}
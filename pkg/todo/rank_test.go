@@ -0,0 +1,127 @@
+package todo
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		lo, hi string
+		want   string
+	}{
+		{"", "", "i"},
+		{"a", "", "n"},
+		{"", "a", "5"},
+		{"a", "c", "b"},
+		{"a", "b", "ai"},
+		{"", "1", "0i"},
+		{"z", "", "zi"},
+		{"a", "a1", "a0i"},
+		{"a0", "b", "ai"},
+		{"a", "a0", ""},
+		{"b", "a", ""},
+		{"a", "a", ""},
+	}
+	for _, tt := range tests {
+		got := rankBetween(tt.lo, tt.hi)
+		if got != tt.want {
+			t.Errorf("rankBetween(%q, %q) = %q, want %q", tt.lo, tt.hi, got, tt.want)
+		}
+		if got != "" && !validRank(got, tt.lo, tt.hi) {
+			t.Errorf("rankBetween(%q, %q) = %q does not sort between them", tt.lo, tt.hi, got)
+		}
+	}
+}
+
+// TestRankBetweenRepeated drops keys at the front, the back and into the
+// same gap over and over, the way a list is reordered by hand.
+func TestRankBetweenRepeated(t *testing.T) {
+	ranks := []string{rankBetween("", "")}
+	insert := func(i int) {
+		lo, hi := "", ""
+		if i > 0 {
+			lo = ranks[i-1]
+		}
+		if i < len(ranks) {
+			hi = ranks[i]
+		}
+		key := rankBetween(lo, hi)
+		if !validRank(key, lo, hi) {
+			t.Fatalf("rankBetween(%q, %q) = %q", lo, hi, key)
+		}
+		ranks = append(ranks[:i], append([]string{key}, ranks[i:]...)...)
+	}
+
+	for i := 0; i < 20; i++ {
+		insert(0)
+		insert(len(ranks))
+		insert(len(ranks) / 2)
+	}
+	if !sort.StringsAreSorted(ranks) {
+		t.Errorf("ranks out of order: %q", ranks)
+	}
+	for _, key := range ranks {
+		if len(key) > maxRankLength {
+			t.Errorf("rank %q is longer than %d", key, maxRankLength)
+		}
+	}
+}
+
+func TestEvenRanks(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 35, 36, 100, 2000} {
+		ranks := evenRanks(n)
+		if len(ranks) != n {
+			t.Fatalf("evenRanks(%d) returned %d keys", n, len(ranks))
+		}
+		for i, key := range ranks {
+			lo := ""
+			if i > 0 {
+				lo = ranks[i-1]
+			}
+			if !validRank(key, lo, "") {
+				t.Fatalf("evenRanks(%d)[%d] = %q does not follow %q", n, i, key, lo)
+			}
+		}
+		if n > 0 && rankBetween(ranks[n-1], "") == "" {
+			t.Errorf("evenRanks(%d) leaves no room after the last key", n)
+		}
+	}
+}
+
+func TestAppendRanks(t *testing.T) {
+	listID := primitive.NewObjectID()
+	todos := []models.Todo{
+		{ID: primitive.NewObjectID(), ListID: listID, Rank: "i"},
+		{ID: primitive.NewObjectID(), ListID: listID, Rank: "n"},
+		{ID: primitive.NewObjectID(), Rank: "z"},
+	}
+
+	for _, n := range []int{1, 5, 100} {
+		// Neither case needs a rebalance, so no collection is touched.
+		ranks, err := appendRanks(nil, nil, "", todos, listID, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranks) != n || !sort.StringsAreSorted(ranks) || ranks[0] <= "n" {
+			t.Errorf("appendRanks(%d) = %q, want %d sorted keys after %q", n, ranks, n, "n")
+		}
+		for _, key := range ranks {
+			if len(key) > maxRankLength {
+				t.Errorf("appendRanks(%d) made %q, longer than %d", n, key, maxRankLength)
+			}
+		}
+
+		empty, err := appendRanks(nil, nil, "", todos, primitive.NewObjectID(), n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := evenRanks(n); strings.Join(empty, ",") != strings.Join(want, ",") {
+			t.Errorf("appendRanks(%d) on an empty list = %q, want %q", n, empty, want)
+		}
+	}
+}
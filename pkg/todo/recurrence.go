@@ -0,0 +1,565 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/pkg/comment"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func resetSubtasks(subtasks []models.Subtask) []models.Subtask {
+	if subtasks == nil {
+		return nil
+	}
+	reset := make([]models.Subtask, len(subtasks))
+	for i, subtask := range subtasks {
+		subtask.ID = primitive.NewObjectID()
+		subtask.Done = false
+		reset[i] = subtask
+	}
+	return reset
+}
+
+func recurrenceTemplate(todo models.Todo) *models.Todo {
+	template := todo
+	template.ID = primitive.NilObjectID
+	template.DueDate = nil
+	template.Done = false
+	template.CompletedAt = nil
+	template.Recurrence = nil
+	template.Subtask = resetSubtasks(todo.Subtask)
+	template.Attachments = nil
+	template.CommentCount = 0
+	template.ColumnID = primitive.NilObjectID
+	template.Reminders = nil
+	for _, reminder := range todo.Reminders {
+		if reminder.At == nil {
+			template.Reminders = append(template.Reminders, reminder)
+		}
+	}
+	return &template
+}
+
+func initRecurrence(todo *models.Todo, user models.User) error {
+	if todo.Recurrence == nil || todo.Recurrence.Rule == "" {
+		todo.Recurrence = nil
+		return nil
+	}
+
+	if todo.DueDate == nil {
+		return fmt.Errorf("recurring todos need a due date")
+	}
+
+	if _, err := parseRRule(todo.Recurrence.Rule, todoLocation(*todo, user)); err != nil {
+		return err
+	}
+
+	todo.Recurrence = &models.Recurrence{
+		Rule:     todo.Recurrence.Rule,
+		SeriesID: todo.ID,
+		Start:    *todo.DueDate,
+		Index:    1,
+		Template: recurrenceTemplate(*todo),
+	}
+	return nil
+}
+
+func nextOccurrence(todo models.Todo, user models.User) (models.Todo, bool, error) {
+	recurrence := todo.Recurrence
+	loc := todoLocation(todo, user)
+
+	rule, err := parseRRule(recurrence.Rule, loc)
+	if err != nil {
+		return models.Todo{}, false, err
+	}
+
+	after := recurrence.Start
+	if todo.DueDate != nil {
+		after = *todo.DueDate
+	}
+
+	due, index, ok := rule.next(recurrence.Start, after, loc)
+	if !ok {
+		return models.Todo{}, false, nil
+	}
+
+	template := recurrence.Template
+	if template == nil {
+		template = recurrenceTemplate(todo)
+	}
+
+	next := *template
+	next.ID = primitive.NewObjectID()
+	next.DueDate = &due
+	next.Subtask = resetSubtasks(template.Subtask)
+	next.Recurrence = &models.Recurrence{
+		Rule:     recurrence.Rule,
+		SeriesID: recurrence.SeriesID,
+		Start:    recurrence.Start,
+		Index:    index,
+		Template: template,
+	}
+	return next, true, nil
+}
+
+// untouchedOccurrence reports whether a spawned occurrence is still as it
+// was created, so that it can be dropped without losing anything.
+func untouchedOccurrence(todos []models.Todo, next models.Todo) bool {
+	if next.Done || next.CommentCount > 0 || len(next.Attachments) > 0 || len(dependentsOf(todos, next.ID)) > 0 {
+		return false
+	}
+	for _, subtask := range next.Subtask {
+		if subtask.Done {
+			return false
+		}
+	}
+	return true
+}
+
+func updatedRecurrence(existing models.Todo, updated models.Todo, scope string, user models.User) (*models.Recurrence, error) {
+	merged := updated
+	merged.ID = existing.ID
+	if merged.Subtask == nil {
+		merged.Subtask = existing.Subtask
+	}
+
+	// Clients echo the todo back on every edit, so the series is only rebuilt
+	// when the rule itself changes. Rebuilding restarts COUNT and UNTIL and
+	// forgets the occurrence that was already spawned.
+	unchanged := updated.Recurrence != nil && existing.Recurrence != nil && updated.Recurrence.Rule == existing.Recurrence.Rule
+	if updated.Recurrence != nil && !unchanged {
+		if scope == "this" {
+			return nil, fmt.Errorf("the rule can only be changed for all future occurrences")
+		}
+		if err := initRecurrence(&merged, user); err != nil {
+			return nil, err
+		}
+		if merged.Recurrence != nil && existing.Recurrence != nil {
+			merged.Recurrence.SeriesID = existing.Recurrence.SeriesID
+		}
+		return merged.Recurrence, nil
+	}
+
+	if existing.Recurrence == nil {
+		return nil, nil
+	}
+
+	recurrence := *existing.Recurrence
+	if scope == "future" {
+		recurrence.Template = recurrenceTemplate(merged)
+	}
+	return &recurrence, nil
+}
+
+func CompleteTodo(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		todo := user.Todo[index]
+		if todo.Done {
+			http.Error(w, "Todo already completed", http.StatusConflict)
+			return
+		}
+
+		withBlocked(user.Todo)
+		if user.Todo[index].Blocked && r.URL.Query().Get("force") != "true" {
+			http.Error(w, "Todo is blocked by unfinished prerequisites", http.StatusConflict)
+			return
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, todoID)
+
+		// A reopened occurrence whose next instance is still around must not
+		// spawn another one when it is completed again.
+		var next *models.Todo
+		spawn := false
+		if todo.Recurrence != nil {
+			if spawned := findTodo(user.Todo, todo.Recurrence.NextID); !todo.Recurrence.NextID.IsZero() && spawned != -1 {
+				next = &user.Todo[spawned]
+			} else {
+				occurrence, ok, err := nextOccurrence(todo, user)
+				if err != nil {
+					log.Println("Error computing next occurrence:", err)
+					http.Error(w, "Failed to compute next occurrence", http.StatusInternalServerError)
+					return
+				}
+				if ok {
+					next = &occurrence
+					spawn = true
+				}
+			}
+		}
+
+		completedAt := time.Now()
+		todoUpdate := bson.M{"done": true, "completed_at": completedAt}
+		userUpdate := bson.M{"todos.$.done": true, "todos.$.completed_at": completedAt}
+		if spawn {
+			todoUpdate["recurrence.next_id"] = next.ID
+			userUpdate["todos.$.recurrence.next_id"] = next.ID
+		}
+
+		_, err = collection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": todoID},
+			bson.M{"$set": todoUpdate},
+		)
+		if err != nil {
+			http.Error(w, "Failed to complete todo", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID, "todos._id": todoID},
+			bson.M{"$set": userUpdate},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update user's todo list", http.StatusInternalServerError)
+			return
+		}
+
+		user.Todo[index].Done = true
+		response := map[string]interface{}{
+			"message":      "Todo completed successfully",
+			"id":           todoID.Hex(),
+			"completed_at": completedAt,
+			"unblocked":    unblockedBy(user.Todo, todoID),
+		}
+
+		if spawn {
+			recorder.Track(activity.ItemTodo, next.ID)
+			if _, err := collection.InsertOne(context.TODO(), next); err != nil {
+				log.Println("Error inserting next occurrence:", err)
+				http.Error(w, "Failed to create next occurrence", http.StatusInternalServerError)
+				return
+			}
+
+			_, err = userCollection.UpdateOne(
+				context.TODO(),
+				bson.M{"_id": user.ID},
+				bson.M{"$push": bson.M{"todos": next}},
+			)
+			if err != nil {
+				http.Error(w, "Failed to update user with next occurrence", http.StatusInternalServerError)
+				return
+			}
+		}
+		if next != nil {
+			response["next"] = next
+		}
+		recorder.Commit()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func ReopenTodo(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, todoID)
+
+		// The occurrence spawned on completion goes away again unless it has
+		// been worked on, in which case it is kept and completing this one
+		// again will not spawn a second one.
+		todo := user.Todo[index]
+		reopened := todo
+		reopened.Done = false
+		if err := checkPlacement(user.Todo, user.List, &todo, reopened); err != nil {
+			http.Error(w, "Cannot reopen todo: "+err.Error(), http.StatusConflict)
+			return
+		}
+
+		todoUnset := bson.M{"completed_at": ""}
+		userUnset := bson.M{"todos.$.completed_at": ""}
+		if todo.Recurrence != nil && !todo.Recurrence.NextID.IsZero() {
+			nextID := todo.Recurrence.NextID
+			spawned := findTodo(user.Todo, nextID)
+			if spawned != -1 && untouchedOccurrence(user.Todo, user.Todo[spawned]) {
+				recorder.Track(activity.ItemTodo, nextID)
+				if _, err := collection.DeleteOne(context.TODO(), bson.M{"_id": nextID}); err != nil {
+					http.Error(w, "Failed to remove next occurrence", http.StatusInternalServerError)
+					return
+				}
+				_, err = userCollection.UpdateOne(
+					context.TODO(),
+					bson.M{"_id": user.ID},
+					bson.M{"$pull": bson.M{"todos": bson.M{"_id": nextID}}},
+				)
+				if err != nil {
+					http.Error(w, "Failed to update user todo list", http.StatusInternalServerError)
+					return
+				}
+				spawned = -1
+			}
+			if spawned == -1 {
+				todoUnset["recurrence.next_id"] = ""
+				userUnset["todos.$.recurrence.next_id"] = ""
+			}
+		}
+
+		_, err = collection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": todoID},
+			bson.M{"$set": bson.M{"done": false}, "$unset": todoUnset},
+		)
+		if err != nil {
+			http.Error(w, "Failed to reopen todo", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID, "todos._id": todoID},
+			bson.M{"$set": bson.M{"todos.$.done": false}, "$unset": userUnset},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update user's todo list", http.StatusInternalServerError)
+			return
+		}
+
+		recorder.Commit()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Todo reopened successfully",
+			"id":      todoID.Hex(),
+		})
+	}
+}
+
+func SkipOccurrence(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		todo := user.Todo[index]
+		if todo.Recurrence == nil {
+			http.Error(w, "Todo is not recurring", http.StatusBadRequest)
+			return
+		}
+		// Completing already moved the series on to the next occurrence.
+		if todo.Done || !todo.Recurrence.NextID.IsZero() {
+			http.Error(w, "Occurrence already completed", http.StatusConflict)
+			return
+		}
+
+		next, ok, err := nextOccurrence(todo, user)
+		if err != nil {
+			log.Println("Error computing next occurrence:", err)
+			http.Error(w, "Failed to compute next occurrence", http.StatusInternalServerError)
+			return
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		recorder.Track(activity.ItemTodo, todoID)
+
+		if !ok {
+			recorder.Track(activity.ItemTodo, dependentsOf(user.Todo, todoID)...)
+
+			if _, err := collection.DeleteOne(context.TODO(), bson.M{"_id": todoID}); err != nil {
+				http.Error(w, "Error deleting todo", http.StatusInternalServerError)
+				return
+			}
+
+			_, err = userCollection.UpdateOne(
+				context.TODO(),
+				bson.M{"_id": user.ID},
+				bson.M{"$pull": bson.M{"todos": bson.M{"_id": todoID}}},
+			)
+			if err != nil {
+				http.Error(w, "Failed to update user todo list", http.StatusInternalServerError)
+				return
+			}
+
+			if err := RemoveDependency(collection, userCollection, user.ID, todoID); err != nil {
+				log.Println("Failed to remove dependency on deleted todo:", err)
+			}
+			if err := comment.RemoveForTodos(context.TODO(), collection.Database(), todoID); err != nil {
+				log.Println("Failed to remove comments of deleted todo:", err)
+			}
+			recorder.Commit()
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "Last occurrence skipped, series ended",
+				"id":      todoID.Hex(),
+			})
+			return
+		}
+
+		_, err = collection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": todoID},
+			bson.M{"$set": bson.M{
+				"due_date":         next.DueDate,
+				"sub_task":         next.Subtask,
+				"recurrence.index": next.Recurrence.Index,
+			}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to skip occurrence", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID, "todos._id": todoID},
+			bson.M{"$set": bson.M{
+				"todos.$.due_date":         next.DueDate,
+				"todos.$.sub_task":         next.Subtask,
+				"todos.$.recurrence.index": next.Recurrence.Index,
+			}},
+		)
+		if err != nil {
+			http.Error(w, "Failed to update user's todo list", http.StatusInternalServerError)
+			return
+		}
+
+		recorder.Commit()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Occurrence skipped successfully",
+			"id":       todoID.Hex(),
+			"due_date": next.DueDate,
+			"index":    next.Recurrence.Index,
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package todo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/userAdityaa/todo-backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdatedRecurrence(t *testing.T) {
+	user := models.User{TimeZone: "UTC"}
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	due := start.AddDate(0, 0, 7)
+
+	existing := models.Todo{ID: primitive.NewObjectID(), Name: "Water plants", DueDate: &start}
+	existing.Recurrence = &models.Recurrence{Rule: "FREQ=WEEKLY;COUNT=4"}
+	if err := initRecurrence(&existing, user); err != nil {
+		t.Fatal(err)
+	}
+	existing.DueDate = &due
+	existing.Recurrence.Index = 2
+	existing.Recurrence.NextID = primitive.NewObjectID()
+
+	echo := func(rule string) models.Todo {
+		updated := existing
+		updated.Name = "Water the plants"
+		updated.Recurrence = &models.Recurrence{Rule: rule}
+		return updated
+	}
+
+	for _, scope := range []string{"", "this", "future"} {
+		recurrence, err := updatedRecurrence(existing, echo("FREQ=WEEKLY;COUNT=4"), scope, user)
+		if err != nil {
+			t.Fatalf("scope %q: echoing the rule back = %v, want no error", scope, err)
+		}
+		if !recurrence.Start.Equal(start) || recurrence.Index != 2 || recurrence.NextID != existing.Recurrence.NextID {
+			t.Errorf("scope %q: echoing the rule back restarted the series: %+v", scope, recurrence)
+		}
+		if scope == "future" && recurrence.Template.Name != "Water the plants" {
+			t.Errorf("scope %q: template name = %q, want the edited name", scope, recurrence.Template.Name)
+		}
+	}
+
+	recurrence, err := updatedRecurrence(existing, echo("FREQ=DAILY"), "future", user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recurrence.Rule != "FREQ=DAILY" || !recurrence.Start.Equal(due) || recurrence.Index != 1 || recurrence.SeriesID != existing.Recurrence.SeriesID {
+		t.Errorf("changing the rule = %+v, want a series restarted at the due date", recurrence)
+	}
+
+	if _, err := updatedRecurrence(existing, echo("FREQ=DAILY"), "this", user); err == nil {
+		t.Error("changing the rule for this occurrence only succeeded")
+	}
+}
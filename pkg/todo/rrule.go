@@ -0,0 +1,275 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxRecurrencePeriods = 10000
+
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+type rrule struct {
+	freq       string
+	interval   int
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []time.Month
+	count      int
+	until      time.Time
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+func parseRRule(value string, loc *time.Location) (rrule, error) {
+	rule := rrule{interval: 1}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return rule, fmt.Errorf("empty recurrence rule")
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("malformed rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+			if rule.freq != "DAILY" && rule.freq != "WEEKLY" && rule.freq != "MONTHLY" && rule.freq != "YEARLY" {
+				return rule, fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return rule, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return rule, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rule.count = count
+		case "UNTIL":
+			until, err := parseRRuleUntil(val, loc)
+			if err != nil {
+				return rule, err
+			}
+			rule.until = until
+		case "BYDAY":
+			for _, item := range strings.Split(val, ",") {
+				item = strings.ToUpper(strings.TrimSpace(item))
+				if len(item) < 2 {
+					return rule, fmt.Errorf("invalid BYDAY %q", item)
+				}
+				day, ok := rruleWeekdays[item[len(item)-2:]]
+				if !ok {
+					return rule, fmt.Errorf("invalid BYDAY %q", item)
+				}
+				n := 0
+				if prefix := item[:len(item)-2]; prefix != "" {
+					parsed, err := strconv.Atoi(prefix)
+					if err != nil || parsed == 0 || parsed < -5 || parsed > 5 {
+						return rule, fmt.Errorf("invalid BYDAY %q", item)
+					}
+					n = parsed
+				}
+				rule.byDay = append(rule.byDay, weekdayNum{n: n, day: day})
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(val, ",") {
+				day, err := strconv.Atoi(strings.TrimSpace(item))
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return rule, fmt.Errorf("invalid BYMONTHDAY %q", item)
+				}
+				rule.byMonthDay = append(rule.byMonthDay, day)
+			}
+		case "BYMONTH":
+			for _, item := range strings.Split(val, ",") {
+				month, err := strconv.Atoi(strings.TrimSpace(item))
+				if err != nil || month < 1 || month > 12 {
+					return rule, fmt.Errorf("invalid BYMONTH %q", item)
+				}
+				rule.byMonth = append(rule.byMonth, time.Month(month))
+			}
+		case "WKST":
+		default:
+			return rule, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if rule.freq == "" {
+		return rule, fmt.Errorf("FREQ is required")
+	}
+	if rule.count > 0 && !rule.until.IsZero() {
+		return rule, fmt.Errorf("COUNT and UNTIL cannot both be set")
+	}
+	return rule, nil
+}
+
+func parseRRuleUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func (r rrule) next(start time.Time, after time.Time, loc *time.Location) (time.Time, int, bool) {
+	start = start.In(loc)
+	index := 1
+
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		for _, candidate := range r.candidates(start, period*r.interval, loc) {
+			if !candidate.After(start) {
+				continue
+			}
+			index++
+			if r.count > 0 && index > r.count {
+				return time.Time{}, 0, false
+			}
+			if !r.until.IsZero() && candidate.After(r.until) {
+				return time.Time{}, 0, false
+			}
+			if candidate.After(after) {
+				return candidate, index, true
+			}
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+func (r rrule) candidates(start time.Time, offset int, loc *time.Location) []time.Time {
+	hour, minute, second := start.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, loc)
+	}
+
+	var candidates []time.Time
+	switch r.freq {
+	case "DAILY":
+		day := at(start.Year(), start.Month(), start.Day()+offset)
+		if r.matchesWeekday(day) && r.matchesMonth(day) {
+			candidates = append(candidates, day)
+		}
+	case "WEEKLY":
+		weekStart := at(start.Year(), start.Month(), start.Day()-(int(start.Weekday())+6)%7+offset*7)
+		if len(r.byDay) == 0 {
+			candidates = append(candidates, weekStart.AddDate(0, 0, (int(start.Weekday())+6)%7))
+			break
+		}
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if r.matchesWeekday(day) && r.matchesMonth(day) {
+				candidates = append(candidates, day)
+			}
+		}
+	case "MONTHLY":
+		month := time.Date(start.Year(), start.Month()+time.Month(offset), 1, 0, 0, 0, 0, loc)
+		if !r.matchesMonth(month) {
+			break
+		}
+		candidates = r.monthCandidates(month.Year(), month.Month(), start.Day(), at)
+	case "YEARLY":
+		year := start.Year() + offset
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		for _, month := range months {
+			candidates = append(candidates, r.monthCandidates(year, month, start.Day(), at)...)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	return candidates
+}
+
+func (r rrule) monthCandidates(year int, month time.Month, defaultDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	var candidates []time.Time
+
+	if len(r.byMonthDay) > 0 {
+		for _, day := range r.byMonthDay {
+			if day < 0 {
+				day = daysInMonth + day + 1
+			}
+			if day >= 1 && day <= daysInMonth {
+				candidates = append(candidates, at(year, month, day))
+			}
+		}
+		return candidates
+	}
+
+	if len(r.byDay) > 0 {
+		for _, wd := range r.byDay {
+			var matches []int
+			for day := 1; day <= daysInMonth; day++ {
+				if time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday() == wd.day {
+					matches = append(matches, day)
+				}
+			}
+			switch {
+			case wd.n == 0:
+				for _, day := range matches {
+					candidates = append(candidates, at(year, month, day))
+				}
+			case wd.n > 0 && wd.n <= len(matches):
+				candidates = append(candidates, at(year, month, matches[wd.n-1]))
+			case wd.n < 0 && -wd.n <= len(matches):
+				candidates = append(candidates, at(year, month, matches[len(matches)+wd.n]))
+			}
+		}
+		return candidates
+	}
+
+	if defaultDay <= daysInMonth {
+		candidates = append(candidates, at(year, month, defaultDay))
+	}
+	return candidates
+}
+
+func (r rrule) matchesWeekday(t time.Time) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	for _, wd := range r.byDay {
+		if wd.day == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rrule) matchesMonth(t time.Time) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, month := range r.byMonth {
+		if month == t.Month() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRRule(t *testing.T) {
+	valid := []string{
+		"FREQ=DAILY",
+		"RRULE:FREQ=weekly;byday=mo,-1fr;WKST=MO",
+		"FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=1,-1",
+		"FREQ=YEARLY;BYMONTH=1,7;COUNT=4",
+		"FREQ=DAILY;UNTIL=20240103T000000Z",
+	}
+	for _, value := range valid {
+		if _, err := parseRRule(value, time.UTC); err != nil {
+			t.Errorf("parseRRule(%q) = %v, want no error", value, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"FREQ",
+		"FREQ=HOURLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;BYSETPOS=1",
+	}
+	for _, value := range invalid {
+		if _, err := parseRRule(value, time.UTC); err == nil {
+			t.Errorf("parseRRule(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestRRuleNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data unavailable:", err)
+	}
+	at := func(loc *time.Location, year int, month time.Month, day int, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+	utc := func(year int, month time.Month, day int) time.Time {
+		return at(time.UTC, year, month, day, 9)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		loc   *time.Location
+		start time.Time
+		after time.Time
+		want  time.Time
+		index int
+		ok    bool
+	}{
+		{"daily", "FREQ=DAILY", time.UTC, utc(2024, 1, 1), utc(2024, 1, 1), utc(2024, 1, 2), 2, true},
+		{"daily interval", "FREQ=DAILY;INTERVAL=3", time.UTC, utc(2024, 1, 1), utc(2024, 1, 5), utc(2024, 1, 7), 3, true},
+		{"weekly by day", "FREQ=WEEKLY;BYDAY=MO,WE,FR", time.UTC, utc(2024, 1, 1), utc(2024, 1, 1), utc(2024, 1, 3), 2, true},
+		{"weekly by day next week", "FREQ=WEEKLY;BYDAY=MO,WE,FR", time.UTC, utc(2024, 1, 1), utc(2024, 1, 5), utc(2024, 1, 8), 4, true},
+		{"every other week", "FREQ=WEEKLY;INTERVAL=2", time.UTC, utc(2024, 1, 3), utc(2024, 1, 3), utc(2024, 1, 17), 2, true},
+		{"monthly skips short months", "FREQ=MONTHLY", time.UTC, utc(2024, 1, 31), utc(2024, 1, 31), utc(2024, 3, 31), 2, true},
+		{"last day of month", "FREQ=MONTHLY;BYMONTHDAY=-1", time.UTC, utc(2024, 1, 31), utc(2024, 1, 31), utc(2024, 2, 29), 2, true},
+		{"second tuesday", "FREQ=MONTHLY;BYDAY=2TU", time.UTC, utc(2024, 1, 9), utc(2024, 1, 9), utc(2024, 2, 13), 2, true},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", time.UTC, utc(2024, 1, 26), utc(2024, 1, 26), utc(2024, 2, 23), 2, true},
+		{"yearly leap day", "FREQ=YEARLY", time.UTC, utc(2024, 2, 29), utc(2024, 2, 29), utc(2028, 2, 29), 2, true},
+		{"yearly by month", "FREQ=YEARLY;BYMONTH=1,7", time.UTC, utc(2024, 1, 15), utc(2024, 1, 15), utc(2024, 7, 15), 2, true},
+		{"last counted occurrence", "FREQ=DAILY;COUNT=3", time.UTC, utc(2024, 1, 1), utc(2024, 1, 2), utc(2024, 1, 3), 3, true},
+		{"count exhausted", "FREQ=DAILY;COUNT=3", time.UTC, utc(2024, 1, 1), utc(2024, 1, 3), time.Time{}, 0, false},
+		{"until date is inclusive", "FREQ=DAILY;UNTIL=20240103", time.UTC, utc(2024, 1, 1), utc(2024, 1, 2), utc(2024, 1, 3), 3, true},
+		{"until passed", "FREQ=DAILY;UNTIL=20240103", time.UTC, utc(2024, 1, 1), utc(2024, 1, 3), time.Time{}, 0, false},
+		{"keeps wall clock across DST", "FREQ=DAILY", newYork, at(newYork, 2024, 3, 9, 9), at(newYork, 2024, 3, 9, 9), at(newYork, 2024, 3, 10, 9), 2, true},
+		{"after far ahead of start", "FREQ=WEEKLY", time.UTC, utc(2024, 1, 1), utc(2024, 12, 31), utc(2025, 1, 6), 54, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRRule(tt.rule, tt.loc)
+			if err != nil {
+				t.Fatalf("parseRRule(%q): %v", tt.rule, err)
+			}
+			got, index, ok := rule.next(tt.start, tt.after, tt.loc)
+			if ok != tt.ok || index != tt.index || !got.Equal(tt.want) {
+				t.Errorf("next = (%v, %d, %v), want (%v, %d, %v)", got, index, ok, tt.want, tt.index, tt.ok)
+			}
+		})
+	}
+}
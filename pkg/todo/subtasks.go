@@ -0,0 +1,523 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func normalizeSubtasks(subtasks []models.Subtask) {
+	sort.SliceStable(subtasks, func(i, j int) bool {
+		return subtasks[i].Position < subtasks[j].Position
+	})
+	for i := range subtasks {
+		if subtasks[i].ID.IsZero() {
+			subtasks[i].ID = primitive.NewObjectID()
+		}
+		subtasks[i].Position = i
+	}
+}
+
+func subtaskProgress(subtasks []models.Subtask) models.SubtaskProgress {
+	progress := models.SubtaskProgress{Total: len(subtasks)}
+	for _, subtask := range subtasks {
+		if subtask.Done {
+			progress.Done++
+		}
+	}
+	return progress
+}
+
+func withProgress(todos []models.Todo) {
+	for i := range todos {
+		todos[i].Progress = subtaskProgress(todos[i].Subtask)
+	}
+}
+
+func DecorateTodos(todos []models.Todo, user models.User, now time.Time) {
+	withProgress(todos)
+	withDueStatus(todos, user, now)
+	withBlocked(todos)
+}
+
+func findTodo(todos []models.Todo, id primitive.ObjectID) int {
+	for i, todo := range todos {
+		if todo.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func ownsList(user models.User, listID primitive.ObjectID) bool {
+	if listID.IsZero() {
+		return true
+	}
+	for _, list := range user.List {
+		if list.ID == listID {
+			return true
+		}
+	}
+	return false
+}
+
+func findSubtask(subtasks []models.Subtask, id primitive.ObjectID) int {
+	for i, subtask := range subtasks {
+		if subtask.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func saveSubtasks(collection *mongo.Collection, userCollection *mongo.Collection, userID string, actorID string, todoID primitive.ObjectID, subtasks []models.Subtask) error {
+	recorder := activity.NewRecorder(collection.Database(), userID, actorID)
+	recorder.Track(activity.ItemTodo, todoID)
+
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": todoID},
+		bson.M{"$set": bson.M{"sub_task": subtasks}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = userCollection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": userID, "todos._id": todoID},
+		bson.M{"$set": bson.M{"todos.$.sub_task": subtasks}},
+	)
+	if err != nil {
+		return err
+	}
+
+	recorder.Commit()
+	return nil
+}
+
+func AddSubtask(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		var subtask models.Subtask
+		if err := json.NewDecoder(r.Body).Decode(&subtask); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if subtask.Title == "" {
+			http.Error(w, "Title is a required field", http.StatusBadRequest)
+			return
+		}
+
+		subtasks := user.Todo[index].Subtask
+		subtask.ID = primitive.NewObjectID()
+		subtask.Position = len(subtasks)
+		subtasks = append(subtasks, subtask)
+		normalizeSubtasks(subtasks)
+
+		if err := saveSubtasks(collection, userCollection, user.ID, actorID, todoID, subtasks); err != nil {
+			log.Println("Error saving subtasks:", err)
+			http.Error(w, "Failed to add subtask", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Subtask Created Successfully",
+			"subtask":  subtask,
+			"progress": subtaskProgress(subtasks),
+		})
+	}
+}
+
+func UpdateSubtask(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		subtaskID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "subtaskId"))
+		if err != nil {
+			http.Error(w, "Invalid subtask ID", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		subtasks := user.Todo[index].Subtask
+		subIndex := findSubtask(subtasks, subtaskID)
+		if subIndex == -1 {
+			http.Error(w, "Subtask not found", http.StatusNotFound)
+			return
+		}
+
+		var partialUpdate struct {
+			Title *string `json:"title,omitempty"`
+			Done  *bool   `json:"done,omitempty"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&partialUpdate); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if partialUpdate.Title == nil && partialUpdate.Done == nil {
+			http.Error(w, "No fields to update", http.StatusBadRequest)
+			return
+		}
+
+		if partialUpdate.Title != nil {
+			if *partialUpdate.Title == "" {
+				http.Error(w, "Title cannot be empty", http.StatusBadRequest)
+				return
+			}
+			subtasks[subIndex].Title = *partialUpdate.Title
+		}
+		if partialUpdate.Done != nil {
+			subtasks[subIndex].Done = *partialUpdate.Done
+		}
+
+		if err := saveSubtasks(collection, userCollection, user.ID, actorID, todoID, subtasks); err != nil {
+			log.Println("Error saving subtasks:", err)
+			http.Error(w, "Failed to update subtask", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Subtask updated successfully",
+			"subtask":  subtasks[subIndex],
+			"progress": subtaskProgress(subtasks),
+		})
+	}
+}
+
+func ToggleSubtask(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		subtaskID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "subtaskId"))
+		if err != nil {
+			http.Error(w, "Invalid subtask ID", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		subtasks := user.Todo[index].Subtask
+		subIndex := findSubtask(subtasks, subtaskID)
+		if subIndex == -1 {
+			http.Error(w, "Subtask not found", http.StatusNotFound)
+			return
+		}
+
+		subtasks[subIndex].Done = !subtasks[subIndex].Done
+
+		if err := saveSubtasks(collection, userCollection, user.ID, actorID, todoID, subtasks); err != nil {
+			log.Println("Error saving subtasks:", err)
+			http.Error(w, "Failed to toggle subtask", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Subtask toggled successfully",
+			"subtask":  subtasks[subIndex],
+			"progress": subtaskProgress(subtasks),
+		})
+	}
+}
+
+func ReorderSubtasks(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		var reorderRequest struct {
+			IDs []primitive.ObjectID `json:"ids"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&reorderRequest); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		subtasks := user.Todo[index].Subtask
+		if len(reorderRequest.IDs) != len(subtasks) {
+			http.Error(w, "Reorder must include every subtask exactly once", http.StatusBadRequest)
+			return
+		}
+
+		reordered := make([]models.Subtask, 0, len(subtasks))
+		seen := make(map[primitive.ObjectID]bool)
+		for position, id := range reorderRequest.IDs {
+			subIndex := findSubtask(subtasks, id)
+			if subIndex == -1 || seen[id] {
+				http.Error(w, "Reorder must include every subtask exactly once", http.StatusBadRequest)
+				return
+			}
+			seen[id] = true
+			subtask := subtasks[subIndex]
+			subtask.Position = position
+			reordered = append(reordered, subtask)
+		}
+
+		if err := saveSubtasks(collection, userCollection, user.ID, actorID, todoID, reordered); err != nil {
+			log.Println("Error saving subtasks:", err)
+			http.Error(w, "Failed to reorder subtasks", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Subtasks reordered successfully",
+			"sub_task": reordered,
+		})
+	}
+}
+
+func DeleteSubtask(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		todoID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid ObjectID format", http.StatusBadRequest)
+			return
+		}
+
+		subtaskID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "subtaskId"))
+		if err != nil {
+			http.Error(w, "Invalid subtask ID", http.StatusBadRequest)
+			return
+		}
+
+		actorID := user.ID
+		user, index, ok := todoAccess(w, userCollection, user, todoID, true)
+		if !ok {
+			return
+		}
+
+		subtasks := user.Todo[index].Subtask
+		subIndex := findSubtask(subtasks, subtaskID)
+		if subIndex == -1 {
+			http.Error(w, "Subtask not found", http.StatusNotFound)
+			return
+		}
+
+		subtasks = append(subtasks[:subIndex], subtasks[subIndex+1:]...)
+		normalizeSubtasks(subtasks)
+
+		if err := saveSubtasks(collection, userCollection, user.ID, actorID, todoID, subtasks); err != nil {
+			log.Println("Error saving subtasks:", err)
+			http.Error(w, "Failed to delete subtask", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Subtask deleted successfully",
+			"progress": subtaskProgress(subtasks),
+		})
+	}
+}
@@ -0,0 +1,761 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/config"
+	"github.com/userAdityaa/todo-backend/models"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"github.com/userAdityaa/todo-backend/pkg/tag"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxTemplateTodos  = 200
+	maxTemplateOffset = 3650
+	templateTimeFmt   = "15:04"
+)
+
+func normalizeTemplate(template *models.Template) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(template.Todos) > maxTemplateTodos {
+		return fmt.Errorf("a template can hold at most %d todos", maxTemplateTodos)
+	}
+	if template.Todos == nil {
+		template.Todos = []models.TemplateTodo{}
+	}
+
+	for i := range template.Todos {
+		todo := &template.Todos[i]
+		todo.Name = strings.TrimSpace(todo.Name)
+		if todo.Name == "" {
+			return fmt.Errorf("todo %d: name is required", i+1)
+		}
+		if !validPriority(todo.Priority) {
+			return fmt.Errorf("todo %d: priority must be between 0 and 3", i+1)
+		}
+		if todo.Estimate < 0 {
+			return fmt.Errorf("todo %d: estimate cannot be negative", i+1)
+		}
+		if todo.DueOffsetDays != nil && (*todo.DueOffsetDays < -maxTemplateOffset || *todo.DueOffsetDays > maxTemplateOffset) {
+			return fmt.Errorf("todo %d: due_offset_days must be within %d days", i+1, maxTemplateOffset)
+		}
+		if todo.DueTime != "" {
+			if todo.DueOffsetDays == nil {
+				return fmt.Errorf("todo %d: due_time needs due_offset_days", i+1)
+			}
+			if _, err := time.Parse(templateTimeFmt, todo.DueTime); err != nil {
+				return fmt.Errorf("todo %d: due_time must be in HH:MM format", i+1)
+			}
+		}
+
+		todo.Tags = tag.Normalize(todo.Tags)
+		subtasks := make([]string, 0, len(todo.Subtasks))
+		for _, title := range todo.Subtasks {
+			if title = strings.TrimSpace(title); title != "" {
+				subtasks = append(subtasks, title)
+			}
+		}
+		todo.Subtasks = subtasks
+	}
+	return nil
+}
+
+func civilDays(from time.Time, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// templateFromList captures a list's todos in rank order. Due dates become
+// day offsets from anchor, or from the earliest due date in the list when no
+// anchor is given, and timed todos keep their local time of day.
+func templateFromList(user models.User, listID primitive.ObjectID, anchor *time.Time, includeDone bool) models.Template {
+	template := models.Template{Todos: []models.TemplateTodo{}}
+	for _, list := range user.List {
+		if list.ID == listID {
+			template.ListName = list.Name
+			template.ListColor = list.Color
+		}
+	}
+
+	var members []*models.Todo
+	for _, todo := range ListTodos(user.Todo, listID) {
+		if includeDone || !todo.Done {
+			members = append(members, todo)
+		}
+	}
+
+	if anchor == nil {
+		for _, todo := range members {
+			if todo.DueDate == nil {
+				continue
+			}
+			due := todo.DueDate.In(todoLocation(*todo, user))
+			if anchor == nil || civilDays(*anchor, due) < 0 {
+				anchor = &due
+			}
+		}
+	}
+
+	for _, todo := range members {
+		item := models.TemplateTodo{
+			Name:        todo.Name,
+			Description: todo.Description,
+			Priority:    todo.Priority,
+			Tags:        append([]string{}, todo.Tags...),
+			Subtasks:    make([]string, 0, len(todo.Subtask)),
+			Estimate:    todo.Estimate,
+		}
+		for _, subtask := range todo.Subtask {
+			item.Subtasks = append(item.Subtasks, subtask.Title)
+		}
+
+		if todo.DueDate != nil && anchor != nil {
+			due := todo.DueDate.In(todoLocation(*todo, user))
+			offset := civilDays(*anchor, due)
+			item.DueOffsetDays = &offset
+			if !todo.AllDay {
+				item.DueTime = due.Format(templateTimeFmt)
+			}
+		}
+		template.Todos = append(template.Todos, item)
+	}
+	return template
+}
+
+func instantiateTodo(item models.TemplateTodo, listID primitive.ObjectID, start time.Time, loc *time.Location) models.Todo {
+	todo := models.Todo{
+		ID:          primitive.NewObjectID(),
+		Name:        item.Name,
+		Description: item.Description,
+		ListID:      listID,
+		Priority:    item.Priority,
+		Tags:        append([]string{}, item.Tags...),
+		Estimate:    item.Estimate,
+		Subtask:     make([]models.Subtask, 0, len(item.Subtasks)),
+		BlockedBy:   []primitive.ObjectID{},
+		Reminders:   []models.Reminder{},
+	}
+	for i, title := range item.Subtasks {
+		todo.Subtask = append(todo.Subtask, models.Subtask{Title: title, Position: i})
+	}
+	normalizeSubtasks(todo.Subtask)
+
+	if item.DueOffsetDays != nil {
+		year, month, day := start.Date()
+		due := time.Date(year, month, day+*item.DueOffsetDays, 0, 0, 0, 0, loc)
+		todo.AllDay = true
+		if clock, err := time.Parse(templateTimeFmt, item.DueTime); err == nil && item.DueTime != "" {
+			due = time.Date(year, month, day+*item.DueOffsetDays, clock.Hour(), clock.Minute(), 0, 0, loc)
+			todo.AllDay = false
+		}
+		todo.DueDate = &due
+	}
+	return todo
+}
+
+func GetTemplates(templateCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		cursor, err := templateCollection.Find(
+			context.TODO(),
+			bson.M{"user_id": user.ID},
+			options.Find().SetSort(bson.M{"name": 1}),
+		)
+		if err != nil {
+			http.Error(w, "Failed to fetch templates", http.StatusInternalServerError)
+			return
+		}
+
+		templates := make([]models.Template, 0)
+		if err := cursor.All(context.TODO(), &templates); err != nil {
+			http.Error(w, "Failed to fetch templates", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(templates)
+	}
+}
+
+func GetTemplate(templateCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		templateID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid template ID", http.StatusBadRequest)
+			return
+		}
+
+		var template models.Template
+		err = templateCollection.FindOne(context.TODO(), bson.M{"_id": templateID, "user_id": user.ID}).Decode(&template)
+		if err != nil {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(template)
+	}
+}
+
+func CreateTemplate(templateCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var template models.Template
+		if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := normalizeTemplate(&template); err != nil {
+			http.Error(w, "Invalid template: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		template.ID = primitive.NewObjectID()
+		template.UserID = user.ID
+		template.CreatedAt = time.Now()
+
+		if _, err := templateCollection.InsertOne(context.TODO(), template); err != nil {
+			log.Println("Error inserting template:", err)
+			http.Error(w, "Failed to create template", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Template created successfully",
+			"template": template,
+		})
+	}
+}
+
+func UpdateTemplate(templateCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		templateID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid template ID", http.StatusBadRequest)
+			return
+		}
+
+		var template models.Template
+		if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := normalizeTemplate(&template); err != nil {
+			http.Error(w, "Invalid template: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var updated models.Template
+		err = templateCollection.FindOneAndUpdate(
+			context.TODO(),
+			bson.M{"_id": templateID, "user_id": user.ID},
+			bson.M{"$set": bson.M{
+				"name":        template.Name,
+				"description": template.Description,
+				"list_name":   template.ListName,
+				"list_color":  template.ListColor,
+				"todos":       template.Todos,
+			}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Println("Error updating template:", err)
+			http.Error(w, "Failed to update template", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Template updated successfully",
+			"template": updated,
+		})
+	}
+}
+
+func DeleteTemplate(templateCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		templateID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid template ID", http.StatusBadRequest)
+			return
+		}
+
+		result, err := templateCollection.DeleteOne(context.TODO(), bson.M{"_id": templateID, "user_id": user.ID})
+		if err != nil {
+			http.Error(w, "Error deleting template", http.StatusInternalServerError)
+			return
+		}
+		if result.DeletedCount == 0 {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Template deleted successfully"})
+	}
+}
+
+func SaveListAsTemplate(templateCollection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		var listID primitive.ObjectID
+		if id := chi.URLParam(r, "id"); id != "inbox" {
+			listID, err = primitive.ObjectIDFromHex(id)
+			if err != nil {
+				http.Error(w, "Invalid list ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		account, ok := listAccess(w, userCollection, user, listID, false, http.StatusNotFound)
+		if !ok {
+			return
+		}
+
+		var request struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			AnchorDate  string `json:"anchor_date"`
+			IncludeDone bool   `json:"include_done"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var anchor *time.Time
+		if request.AnchorDate != "" {
+			day, err := time.ParseInLocation("2006-01-02", request.AnchorDate, utils.LoadLocation(user.TimeZone))
+			if err != nil {
+				http.Error(w, "anchor_date must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+			anchor = &day
+		}
+
+		template := templateFromList(account, listID, anchor, request.IncludeDone)
+		template.Name = request.Name
+		if template.Name == "" {
+			template.Name = template.ListName
+		}
+		if template.Name == "" {
+			template.Name = "Inbox"
+		}
+		template.Description = request.Description
+
+		if err := normalizeTemplate(&template); err != nil {
+			http.Error(w, "Invalid template: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		template.ID = primitive.NewObjectID()
+		template.UserID = user.ID
+		template.CreatedAt = time.Now()
+
+		if _, err := templateCollection.InsertOne(context.TODO(), template); err != nil {
+			log.Println("Error inserting template:", err)
+			http.Error(w, "Failed to save template", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  "Template saved successfully",
+			"template": template,
+		})
+	}
+}
+
+func InstantiateTemplate(templateCollection *mongo.Collection, collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		templateID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "Invalid template ID", http.StatusBadRequest)
+			return
+		}
+
+		var template models.Template
+		err = templateCollection.FindOne(context.TODO(), bson.M{"_id": templateID, "user_id": user.ID}).Decode(&template)
+		if err != nil {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		}
+
+		var request struct {
+			StartDate string              `json:"start_date"`
+			ListID    *primitive.ObjectID `json:"list_id,omitempty"`
+			ListName  string              `json:"list_name"`
+			ListColor string              `json:"list_color"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		loc := utils.LoadLocation(user.TimeZone)
+		start := utils.StartOfDay(time.Now(), loc)
+		if request.StartDate != "" {
+			if start, err = time.ParseInLocation("2006-01-02", request.StartDate, loc); err != nil {
+				http.Error(w, "start_date must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Todos land in the given list, or in a new list named after the
+		// template's list, or in the inbox for templates without one.
+		var listID primitive.ObjectID
+		var newList *models.List
+		actorID := user.ID
+		switch {
+		case request.ListID != nil:
+			// Applying a template to a shared list adds the todos to the
+			// list owner's account.
+			user, ok = listAccess(w, userCollection, user, *request.ListID, true, http.StatusBadRequest)
+			if !ok {
+				return
+			}
+			listID = *request.ListID
+		case request.ListName != "" || template.ListName != "":
+			newList = &models.List{
+				ID:    primitive.NewObjectID(),
+				Name:  request.ListName,
+				Color: request.ListColor,
+			}
+			if newList.Name == "" {
+				newList.Name = template.ListName
+			}
+			if newList.Color == "" {
+				newList.Color = template.ListColor
+			}
+			for _, list := range user.List {
+				if list.Position >= newList.Position {
+					newList.Position = list.Position + 1
+				}
+			}
+			listID = newList.ID
+		}
+
+		if newList != nil {
+			if _, err := config.ListCollection(collection.Database()).InsertOne(context.TODO(), newList); err != nil {
+				http.Error(w, "Failed to create list", http.StatusInternalServerError)
+				return
+			}
+			user.List = append(user.List, *newList)
+		}
+
+		recorder := activity.NewRecorder(collection.Database(), user.ID, actorID)
+		created := make([]models.Todo, 0, len(template.Todos))
+		ranks, err := appendRanks(collection, userCollection, user.ID, user.Todo, listID, len(template.Todos))
+		if err != nil {
+			log.Println("Error rebalancing ranks:", err)
+			http.Error(w, "Failed to create todos", http.StatusInternalServerError)
+			return
+		}
+		var tags []string
+		for i, item := range template.Todos {
+			todo := instantiateTodo(item, listID, start, loc)
+			todo.Rank = ranks[i]
+			if err := normalizeDueDate(&todo, user); err != nil {
+				http.Error(w, "Invalid due date: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := checkPlacement(append(append([]models.Todo{}, user.Todo...), created...), user.List, nil, todo); err != nil {
+				http.Error(w, "Cannot place todo: "+err.Error(), http.StatusConflict)
+				return
+			}
+			created = append(created, todo)
+			tags = append(tags, todo.Tags...)
+			recorder.Track(activity.ItemTodo, todo.ID)
+		}
+
+		if len(created) > 0 {
+			documents := make([]interface{}, len(created))
+			for i, todo := range created {
+				documents[i] = todo
+			}
+			if _, err := collection.InsertMany(context.TODO(), documents); err != nil {
+				log.Println("Error inserting template todos:", err)
+				http.Error(w, "Failed to create todos", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// Pushing rather than writing the arrays back keeps todos and lists
+		// added by other requests in the meantime.
+		push := bson.M{"todos": bson.M{"$each": created}}
+		if newList != nil {
+			push["list"] = newList
+		}
+		_, err = userCollection.UpdateOne(
+			context.TODO(),
+			bson.M{"_id": user.ID},
+			bson.M{"$push": push},
+		)
+		if err != nil {
+			log.Println("Error updating user:", err)
+			http.Error(w, "Failed to update user with new todos", http.StatusInternalServerError)
+			return
+		}
+
+		if err := tag.Register(userCollection, user, tags); err != nil {
+			log.Println("Error registering tags:", err)
+		}
+		recorder.Commit()
+
+		DecorateTodos(created, user, time.Now())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Template applied successfully",
+			"list_id": listID,
+			"list":    newList,
+			"todos":   created,
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/models"
+	Event "github.com/userAdityaa/todo-backend/pkg/event"
+	"github.com/userAdityaa/todo-backend/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const upcomingDays = 7
+
+type agendaItem struct {
+	Type   string        `json:"type"`
+	Time   *time.Time    `json:"time"`
+	AllDay bool          `json:"all_day"`
+	Todo   *models.Todo  `json:"todo,omitempty"`
+	Event  *models.Event `json:"event,omitempty"`
+}
+
+type agendaDay struct {
+	Date  string       `json:"date"`
+	Items []agendaItem `json:"items"`
+}
+
+func openTodos(todos []models.Todo) []models.Todo {
+	open := make([]models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if !todo.Done {
+			open = append(open, todo)
+		}
+	}
+	return open
+}
+
+func todosDueBetween(todos []models.Todo, from time.Time, to time.Time) []models.Todo {
+	due := make([]models.Todo, 0)
+	for _, todo := range todos {
+		if todo.DueDate != nil && !todo.DueDate.Before(from) && todo.DueDate.Before(to) {
+			due = append(due, todo)
+		}
+	}
+	sortTodos(due, []sortKey{{field: "due_date"}, {field: "priority", desc: true}})
+	return due
+}
+
+func buildAgenda(todos []models.Todo, events []models.Event, day time.Time) []agendaItem {
+	next := day.AddDate(0, 0, 1)
+	items := make([]agendaItem, 0)
+
+	for _, todo := range todosDueBetween(todos, day, next) {
+		todo := todo
+		items = append(items, agendaItem{Type: "todo", Time: todo.DueDate, AllDay: todo.AllDay, Todo: &todo})
+	}
+
+	for _, event := range Event.EventsBetween(events, day, next) {
+		event := event
+		start := event.Start
+		items = append(items, agendaItem{Type: "event", Time: &start, AllDay: start.Before(day), Event: &event})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].AllDay != items[j].AllDay {
+			return items[i].AllDay
+		}
+		return items[i].Time.Before(*items[j].Time)
+	})
+	return items
+}
+
+func viewLocation(r *http.Request, user models.User) (*time.Location, error) {
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		return time.LoadLocation(tz)
+	}
+	return utils.LoadLocation(user.TimeZone), nil
+}
+
+func GetSmartView(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		loc, err := viewLocation(r, user)
+		if err != nil {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("tz") != "" {
+			user.TimeZone = loc.String()
+		}
+
+		now := time.Now()
+		DecorateTodos(user.Todo, user, now)
+		shared, err := sharedTodos(userCollection, user, now)
+		if err != nil {
+			log.Println("Error fetching shared todos:", err)
+			http.Error(w, "Failed to fetch view", http.StatusInternalServerError)
+			return
+		}
+		todos := openTodos(append(user.Todo, shared...))
+		today := utils.StartOfDay(now, loc)
+
+		var response interface{}
+		switch chi.URLParam(r, "view") {
+		case "today":
+			overdue := make([]models.Todo, 0)
+			for _, todo := range todos {
+				if todo.Overdue && !todo.DueToday {
+					overdue = append(overdue, todo)
+				}
+			}
+			sortTodos(overdue, []sortKey{{field: "due_date"}})
+			response = map[string]interface{}{
+				"date":    today.Format("2006-01-02"),
+				"overdue": overdue,
+				"today":   buildAgenda(todos, user.Event, today),
+			}
+		case "upcoming":
+			days := upcomingDays
+			if value := r.URL.Query().Get("days"); value != "" {
+				days, err = strconv.Atoi(value)
+				if err != nil || days < 1 || days > 31 {
+					http.Error(w, "Days must be between 1 and 31", http.StatusBadRequest)
+					return
+				}
+			}
+			upcoming := make([]agendaDay, 0, days)
+			for i := 0; i < days; i++ {
+				day := time.Date(today.Year(), today.Month(), today.Day()+i, 0, 0, 0, 0, loc)
+				upcoming = append(upcoming, agendaDay{
+					Date:  day.Format("2006-01-02"),
+					Items: buildAgenda(todos, user.Event, day),
+				})
+			}
+			response = upcoming
+		case "overdue":
+			overdue := make([]models.Todo, 0)
+			for _, todo := range todos {
+				if todo.Overdue {
+					overdue = append(overdue, todo)
+				}
+			}
+			sortTodos(overdue, []sortKey{{field: "due_date"}})
+			response = overdue
+		case "someday":
+			someday := make([]models.Todo, 0)
+			for _, todo := range todos {
+				if todo.DueDate == nil {
+					someday = append(someday, todo)
+				}
+			}
+			sortTodos(someday, []sortKey{{field: "priority", desc: true}, {field: "created"}})
+			response = someday
+		default:
+			http.Error(w, "Unknown view, expected today, upcoming, overdue or someday", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(response)
+		if err != nil {
+			log.Println("Error encoding view:", err)
+			http.Error(w, "Failed to fetch view", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetAgenda(collection *mongo.Collection, userCollection *mongo.Collection) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			return
+		}
+
+		tokenString := authHeader
+		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+			tokenString = authHeader[7:]
+		}
+
+		claims, err := utils.ValidateToken(tokenString)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			http.Error(w, "Invalid token claims: email missing", http.StatusUnauthorized)
+			return
+		}
+
+		var user models.User
+		err = userCollection.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+		if err != nil {
+			log.Println("User not found:", err)
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+
+		loc, err := viewLocation(r, user)
+		if err != nil {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("tz") != "" {
+			user.TimeZone = loc.String()
+		}
+
+		now := time.Now()
+		day := utils.StartOfDay(now, loc)
+		if value := r.URL.Query().Get("date"); value != "" {
+			day, err = time.ParseInLocation("2006-01-02", value, loc)
+			if err != nil {
+				http.Error(w, "Date must be formatted as YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+
+		DecorateTodos(user.Todo, user, now)
+		shared, err := sharedTodos(userCollection, user, now)
+		if err != nil {
+			log.Println("Error fetching shared todos:", err)
+			http.Error(w, "Failed to fetch agenda", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		err = json.NewEncoder(w).Encode(agendaDay{
+			Date:  day.Format("2006-01-02"),
+			Items: buildAgenda(openTodos(append(user.Todo, shared...)), user.Event, day),
+		})
+		if err != nil {
+			log.Println("Error encoding agenda:", err)
+			http.Error(w, "Failed to fetch agenda", http.StatusInternalServerError)
+			return
+		}
+	}
+}
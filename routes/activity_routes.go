@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/activity"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpActivityRoutes(router *chi.Mux, activityCollection *mongo.Collection, userCollection *mongo.Collection) {
+	router.Get("/activity", activity.GetActivity(activityCollection, userCollection))
+	router.Post("/undo", activity.UndoActivity(activityCollection, userCollection))
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/attachment"
+	"github.com/userAdityaa/todo-backend/pkg/storage"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpAttachmentRoutes(router *chi.Mux, attachmentCollection *mongo.Collection, userCollection *mongo.Collection, store storage.Store, cronSecret string) {
+	router.Post("/todos/{id}/attachments", attachment.UploadAttachment(attachment.ItemTodo, attachmentCollection, userCollection, store))
+	router.Get("/todos/{id}/attachments", attachment.GetAttachments(attachment.ItemTodo, attachmentCollection, userCollection))
+	router.Get("/todos/{id}/attachments/{attachmentId}/url", attachment.GetAttachmentURL(attachment.ItemTodo, attachmentCollection, userCollection))
+	router.Delete("/todos/{id}/attachments/{attachmentId}", attachment.DeleteAttachment(attachment.ItemTodo, attachmentCollection, userCollection, store))
+	router.Post("/stickies/{id}/attachments", attachment.UploadAttachment(attachment.ItemSticky, attachmentCollection, userCollection, store))
+	router.Get("/stickies/{id}/attachments", attachment.GetAttachments(attachment.ItemSticky, attachmentCollection, userCollection))
+	router.Get("/stickies/{id}/attachments/{attachmentId}/url", attachment.GetAttachmentURL(attachment.ItemSticky, attachmentCollection, userCollection))
+	router.Delete("/stickies/{id}/attachments/{attachmentId}", attachment.DeleteAttachment(attachment.ItemSticky, attachmentCollection, userCollection, store))
+	router.Get("/attachments/{id}/download", attachment.DownloadAttachment(attachmentCollection, store))
+	router.Get("/cron/attachments", attachment.CronSweep(attachmentCollection, store, cronSecret))
+	router.Post("/cron/attachments", attachment.CronSweep(attachmentCollection, store, cronSecret))
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/comment"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpCommentRoutes(router *chi.Mux, commentCollection *mongo.Collection, userCollection *mongo.Collection) {
+	router.Get("/todos/{id}/comments", comment.GetComments(commentCollection, userCollection))
+	router.Post("/todos/{id}/comments", comment.CreateComment(commentCollection, userCollection))
+	router.Put("/todos/{id}/comments/{commentId}", comment.UpdateComment(commentCollection, userCollection))
+	router.Delete("/todos/{id}/comments/{commentId}", comment.DeleteComment(commentCollection, userCollection))
+	router.Get("/comments/mentions", comment.GetMentions(commentCollection, userCollection))
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/focus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpFocusRoutes(router *chi.Mux, focusCollection *mongo.Collection, userCollection *mongo.Collection) {
+	router.Get("/focus", focus.GetFocus(focusCollection, userCollection))
+	router.Post("/focus/start", focus.StartFocus(focusCollection, userCollection))
+	router.Post("/focus/pause", focus.PauseFocus(focusCollection, userCollection))
+	router.Post("/focus/resume", focus.ResumeFocus(focusCollection, userCollection))
+	router.Post("/focus/finish", focus.FinishFocus(focusCollection, userCollection))
+	router.Get("/focus/settings", focus.GetFocusSettings(userCollection))
+	router.Put("/focus/settings", focus.UpdateFocusSettings(userCollection))
+	router.Get("/focus/stats", focus.GetFocusStats(focusCollection, userCollection))
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/habit"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpHabitRoutes(router *chi.Mux, habitCollection *mongo.Collection, checkInCollection *mongo.Collection, userCollection *mongo.Collection) {
+	router.Get("/habits", habit.GetAllHabits(habitCollection, checkInCollection, userCollection))
+	router.Post("/habits", habit.CreateHabit(habitCollection, checkInCollection, userCollection))
+	router.Get("/habits/heatmap", habit.GetHeatmap(habitCollection, checkInCollection, userCollection))
+	router.Put("/habits/{id}", habit.UpdateHabit(habitCollection, checkInCollection, userCollection))
+	router.Delete("/habits/{id}", habit.DeleteHabit(habitCollection, checkInCollection, userCollection))
+	router.Post("/habits/{id}/check-ins", habit.CheckIn(habitCollection, checkInCollection, userCollection))
+	router.Delete("/habits/{id}/check-ins", habit.UndoCheckIn(habitCollection, checkInCollection, userCollection))
+}
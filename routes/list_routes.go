@@ -6,9 +6,15 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetUpListRoutes(router *chi.Mux, listCollection *mongo.Collection, userCollection *mongo.Collection) {
+func SetUpListRoutes(router *chi.Mux, listCollection *mongo.Collection, userCollection *mongo.Collection, todoCollection *mongo.Collection) {
 	router.Post("/create-list", handlers.CreateList(listCollection, userCollection))
-	router.Delete("/delete-list", handlers.DeleteList(listCollection, userCollection))
 	router.Get("/all-list", handlers.GetAllList(listCollection, userCollection))
+	router.Put("/lists/reorder", handlers.ReorderLists(listCollection, userCollection))
 	router.Get("/lists/{id}", handlers.FindAList(listCollection, userCollection))
+	router.Patch("/lists/{id}", handlers.UpdateList(listCollection, userCollection))
+	router.Delete("/lists/{id}", handlers.DeleteList(listCollection, userCollection, todoCollection))
+	router.Post("/lists/{id}/columns", handlers.AddColumn(listCollection, userCollection))
+	router.Put("/lists/{id}/columns/reorder", handlers.ReorderColumns(listCollection, userCollection))
+	router.Put("/lists/{id}/columns/{columnId}", handlers.UpdateColumn(listCollection, userCollection))
+	router.Delete("/lists/{id}/columns/{columnId}", handlers.DeleteColumn(listCollection, userCollection, todoCollection))
 }
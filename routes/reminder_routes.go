@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/reminder"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpReminderRoutes(router *chi.Mux, scheduler *reminder.Scheduler, userCollection *mongo.Collection, cronSecret string) {
+	router.Get("/cron/reminders", reminder.CronTick(scheduler, cronSecret))
+	router.Post("/cron/reminders", reminder.CronTick(scheduler, cronSecret))
+	router.Get("/notifications", reminder.GetNotificationSettings(userCollection))
+	router.Put("/notifications", reminder.UpdateNotificationSettings(userCollection))
+	router.Post("/notifications/push-subscriptions", reminder.AddPushSubscription(userCollection))
+	router.Delete("/notifications/push-subscriptions", reminder.RemovePushSubscription(userCollection))
+	router.Get("/notifications/vapid-public-key", reminder.GetVAPIDPublicKey(scheduler))
+	router.Post("/notifications/test", reminder.SendTestNotification(scheduler, userCollection))
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/search"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpSearchRoutes(router *chi.Mux, userCollection *mongo.Collection) {
+	router.Get("/search", search.Search(userCollection))
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/notify"
+	"github.com/userAdityaa/todo-backend/pkg/share"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpShareRoutes(router *chi.Mux, memberCollection *mongo.Collection, inviteCollection *mongo.Collection, userCollection *mongo.Collection, mailer notify.Notifier) {
+	router.Get("/lists/{id}/members", share.GetMembers(memberCollection, userCollection))
+	router.Put("/lists/{id}/members/{userId}", share.UpdateMember(memberCollection, userCollection))
+	router.Delete("/lists/{id}/members/{userId}", share.RemoveMember(memberCollection, userCollection))
+	router.Post("/lists/{id}/invites", share.CreateInvite(inviteCollection, userCollection, mailer))
+	router.Get("/lists/{id}/invites", share.GetInvites(inviteCollection, userCollection))
+	router.Delete("/lists/{id}/invites/{inviteId}", share.DeleteInvite(inviteCollection, userCollection))
+	router.Get("/invites/{token}", share.GetInvite(inviteCollection, userCollection))
+	router.Post("/invites/{token}/accept", share.AcceptInvite(inviteCollection, userCollection))
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/stats"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpStatsRoutes(router *chi.Mux, userCollection *mongo.Collection) {
+	router.Get("/stats", stats.GetStats(userCollection))
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/tag"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpTagRoutes(router *chi.Mux, todoCollection *mongo.Collection, userCollection *mongo.Collection) {
+	router.Get("/tags", tag.GetAllTags(todoCollection, userCollection))
+	router.Post("/tags", tag.CreateTag(todoCollection, userCollection))
+	router.Post("/tags/merge", tag.MergeTags(todoCollection, userCollection))
+	router.Put("/tags/{name}", tag.UpdateTag(todoCollection, userCollection))
+	router.Delete("/tags/{name}", tag.DeleteTag(todoCollection, userCollection))
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/todo"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpTemplateRoutes(router *chi.Mux, templateCollection *mongo.Collection, todoCollection *mongo.Collection, userCollection *mongo.Collection) {
+	router.Get("/templates", todo.GetTemplates(templateCollection, userCollection))
+	router.Post("/templates", todo.CreateTemplate(templateCollection, userCollection))
+	router.Get("/templates/{id}", todo.GetTemplate(templateCollection, userCollection))
+	router.Put("/templates/{id}", todo.UpdateTemplate(templateCollection, userCollection))
+	router.Delete("/templates/{id}", todo.DeleteTemplate(templateCollection, userCollection))
+	router.Post("/templates/{id}/instantiate", todo.InstantiateTemplate(templateCollection, todoCollection, userCollection))
+	router.Post("/lists/{id}/save-as-template", todo.SaveListAsTemplate(templateCollection, userCollection))
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/userAdityaa/todo-backend/pkg/timetrack"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetUpTimeTrackRoutes(router *chi.Mux, timeEntryCollection *mongo.Collection, userCollection *mongo.Collection) {
+	router.Get("/timer", timetrack.GetTimer(timeEntryCollection, userCollection))
+	router.Post("/timer/stop", timetrack.StopTimer(timeEntryCollection, userCollection))
+	router.Post("/todos/{id}/timer/start", timetrack.StartTimer(timeEntryCollection, userCollection))
+	router.Get("/todos/{id}/time-entries", timetrack.GetTodoTimeEntries(timeEntryCollection, userCollection))
+	router.Post("/todos/{id}/time-entries", timetrack.CreateTimeEntry(timeEntryCollection, userCollection))
+	router.Put("/time-entries/{id}", timetrack.UpdateTimeEntry(timeEntryCollection, userCollection))
+	router.Delete("/time-entries/{id}", timetrack.DeleteTimeEntry(timeEntryCollection, userCollection))
+	router.Get("/time/totals", timetrack.GetTimeTotals(timeEntryCollection, userCollection))
+}
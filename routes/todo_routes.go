@@ -11,4 +11,24 @@ func SetUpTodoRoutes(router *chi.Mux, collection *mongo.Collection, userCollecti
 	router.Delete("/delete-todo/{id}", todo.DeleteTodo(collection, userCollection))
 	router.Put("/update-todo/{id}", todo.UpdateTodo(collection, userCollection))
 	router.Get("/all-todo", todo.GetAllTodo(collection, userCollection))
+	router.Get("/todos", todo.QueryTodos(collection, userCollection))
+	router.Post("/todos/parse", todo.ParseQuickAdd(collection, userCollection))
+	router.Post("/todos/bulk", todo.BulkTodos(collection, userCollection))
+	router.Get("/todos/views/{view}", todo.GetSmartView(collection, userCollection))
+	router.Get("/todos/matrix", todo.GetMatrix(collection, userCollection))
+	router.Get("/todos/next-actions", todo.GetNextActions(collection, userCollection))
+	router.Get("/agenda", todo.GetAgenda(collection, userCollection))
+	router.Put("/todos/{id}/dependencies", todo.SetDependencies(collection, userCollection))
+	router.Get("/lists/{id}/graph", todo.GetDependencyGraph(collection, userCollection))
+	router.Put("/todos/{id}/move", todo.MoveTodo(collection, userCollection))
+	router.Get("/lists/{id}/board", todo.GetBoard(collection, userCollection))
+	router.Put("/todos/{id}/column", todo.MoveToColumn(collection, userCollection))
+	router.Post("/todos/{id}/complete", todo.CompleteTodo(collection, userCollection))
+	router.Post("/todos/{id}/reopen", todo.ReopenTodo(collection, userCollection))
+	router.Post("/todos/{id}/skip", todo.SkipOccurrence(collection, userCollection))
+	router.Post("/todos/{id}/subtasks", todo.AddSubtask(collection, userCollection))
+	router.Put("/todos/{id}/subtasks/reorder", todo.ReorderSubtasks(collection, userCollection))
+	router.Put("/todos/{id}/subtasks/{subtaskId}", todo.UpdateSubtask(collection, userCollection))
+	router.Put("/todos/{id}/subtasks/{subtaskId}/toggle", todo.ToggleSubtask(collection, userCollection))
+	router.Delete("/todos/{id}/subtasks/{subtaskId}", todo.DeleteSubtask(collection, userCollection))
 }
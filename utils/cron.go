@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// CheckCronSecret authorises a call to a scheduled endpoint, which must carry
+// "Authorization: Bearer <secret>". It fails closed: with no secret
+// configured the endpoint is unavailable rather than open to anyone. It
+// writes the error response itself.
+func CheckCronSecret(w http.ResponseWriter, r *http.Request, secret string) bool {
+	if secret == "" {
+		http.Error(w, "Cron endpoint is not configured", http.StatusServiceUnavailable)
+		return false
+	}
+
+	expected := []byte("Bearer " + secret)
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+		http.Error(w, "Invalid cron secret", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"time"
+	_ "time/tzdata"
+)
+
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+func SameDay(a time.Time, b time.Time, loc *time.Location) bool {
+	return StartOfDay(a, loc).Equal(StartOfDay(b, loc))
+}
+
+// CalendarDay is midnight in loc of the date t was written with. Unlike
+// StartOfDay it does not convert t first, so an all-day date sent as
+// "2026-10-20T00:00:00Z" stays on the 20th west of UTC.
+func CalendarDay(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}